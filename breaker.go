@@ -0,0 +1,84 @@
+package httpanic
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen is the error of Reasons rendered by CircuitBreaker while the
+// circuit is open.
+var errCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreaker behaves like GracefullyRender, and additionally counts the
+// recovered Reasons with a 5xx status. Once there have been more than
+// threshold of them within a window, it stops calling next, and instead
+// renders a Reason with status 503 Service Unavailable and a Retry-After
+// header, until the window ends. Windows are fixed, starting at the first
+// server error after the previous window ended. Reasons with other statuses,
+// like those for bad requests, are not counted.
+//
+// When nested within other middleware from this package, as when every route
+// is wrapped by Gracefully, the outer middleware recovers the Reasons, and
+// renders them with render as described for New, and they are still counted.
+func CircuitBreaker(next http.Handler, render Renderer, threshold int, window time.Duration) http.Handler {
+	return (&breaker{threshold: threshold, window: window, now: time.Now}).handler(next, render)
+}
+
+// breaker is the state of a CircuitBreaker.
+type breaker struct {
+	threshold int
+	window    time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	start time.Time
+	count int
+}
+
+func (b *breaker) handler(next http.Handler, render Renderer) http.Handler {
+	return GracefullyRender(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retry, open := b.open(); open {
+			render(w, Because(errCircuitOpen,
+				WithStatus(http.StatusServiceUnavailable),
+				WithHeader("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))))
+			return
+		}
+		onRecover(r, func(_ *http.Request, reason Reason) {
+			if reason.Status >= http.StatusInternalServerError {
+				b.failed()
+			}
+		})
+		next.ServeHTTP(w, r)
+	}), render)
+}
+
+// failed counts a server error in the current window, starting a new window if
+// the last one has ended.
+func (b *breaker) failed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now := b.now(); !now.Before(b.start.Add(b.window)) {
+		b.start, b.count = now, 0
+	}
+	b.count++
+}
+
+// open reports whether the circuit is open, and if so, how long until the
+// window ends and it closes.
+func (b *breaker) open() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.count <= b.threshold {
+		return 0, false
+	}
+	remaining := b.start.Add(b.window).Sub(b.now())
+	if remaining <= 0 {
+		b.count = 0
+		return 0, false
+	}
+	return remaining, true
+}
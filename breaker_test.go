@@ -0,0 +1,105 @@
+package httpanic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock which only moves when told to.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	var calls int
+	status := http.StatusBadGateway
+	b := &breaker{threshold: 2, window: time.Minute, now: clock.now}
+	handler := b.handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if status != http.StatusOK {
+			panic(Status(status))
+		}
+	}), StatusOnly)
+
+	serve := func(wantStatus, wantCalls int, wantRetryAfter string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != wantStatus {
+			t.Errorf("CircuitBreaker(): status got: %v, want: %v", rec.Code, wantStatus)
+		}
+		if calls != wantCalls {
+			t.Errorf("CircuitBreaker(): handler called %d times, want: %d", calls, wantCalls)
+		}
+		if got := rec.Header().Get("Retry-After"); got != wantRetryAfter {
+			t.Errorf("CircuitBreaker(): Retry-After got: %q, want: %q", got, wantRetryAfter)
+		}
+	}
+
+	// Client errors are not counted.
+	status = http.StatusBadRequest
+	serve(http.StatusBadRequest, 1, "")
+	serve(http.StatusBadRequest, 2, "")
+	serve(http.StatusBadRequest, 3, "")
+
+	// Up to the threshold, the handler is still called.
+	status = http.StatusBadGateway
+	serve(http.StatusBadGateway, 4, "")
+	clock.advance(10 * time.Second)
+	serve(http.StatusBadGateway, 5, "")
+
+	// Exceeding the threshold trips the breaker.
+	clock.advance(10 * time.Second)
+	serve(http.StatusBadGateway, 6, "")
+	serve(http.StatusServiceUnavailable, 6, "40")
+	clock.advance(30 * time.Second)
+	serve(http.StatusServiceUnavailable, 6, "10")
+
+	// Once the window ends, the handler is called again.
+	clock.advance(10 * time.Second)
+	status = http.StatusOK
+	serve(http.StatusOK, 7, "")
+
+	// Failures in the new window are counted afresh.
+	status = http.StatusBadGateway
+	serve(http.StatusBadGateway, 8, "")
+	serve(http.StatusBadGateway, 9, "")
+	serve(http.StatusBadGateway, 10, "")
+	serve(http.StatusServiceUnavailable, 10, "60")
+}
+
+func TestCircuitBreakerNested(t *testing.T) {
+	var calls int
+	b := &breaker{threshold: 1, window: time.Minute, now: (&fakeClock{t: time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)}).now}
+	handler := Gracefully(b.handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls++
+		panic(Status(http.StatusBadGateway))
+	}), StatusOnly))
+	for _, want := range []int{
+		http.StatusBadGateway,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != want {
+			t.Errorf("CircuitBreaker(): status got: %v, want: %v", rec.Code, want)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("CircuitBreaker(): handler called %d times, want: 2", calls)
+	}
+}
@@ -0,0 +1,42 @@
+package httpanic
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Rendering a Reason allocates very little on its own account, but the
+// buffers bodies are encoded into are comparatively large. Endpoints which
+// legitimately serve many errors would otherwise allocate a fresh buffer for
+// each of them, so renderers borrow buffers from bufferPool instead.
+//
+// Buffers are always reset before being handed out, so nothing written while
+// serving one request can leak into the response to another. Buffers which
+// have grown past maxPooledBufferSize are left for the garbage collector, so
+// that a single large body does not pin memory for the life of the process.
+
+// maxPooledBufferSize is the largest capacity of a buffer returned to the pool.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer borrows an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+// putBuffer returns a buffer to the pool. The buffer must not be used after it
+// has been returned.
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBufferSize {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
@@ -0,0 +1,75 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGetBufferIsEmpty(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		b := getBuffer()
+		if b.Len() != 0 {
+			t.Fatalf("getBuffer(): got buffer with %d bytes, want empty", b.Len())
+		}
+		b.WriteString("left over from a previous request")
+		putBuffer(b)
+	}
+}
+
+// discardResponseWriter is a http.ResponseWriter which throws away everything
+// written to it, to keep benchmarks focused on rendering.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
+
+func BenchmarkAsJSON(b *testing.B) {
+	reason := Because(errors.New("this is an error"),
+		WithStatus(http.StatusBadRequest),
+		WithExplanation("Chill, man!"))
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			w := &discardResponseWriter{header: make(http.Header)}
+			for pb.Next() {
+				// This is how AsJSON rendered before it used pooled buffers.
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(reason.Status)
+				if err := json.NewEncoder(w).Encode(reason); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			w := &discardResponseWriter{header: make(http.Header)}
+			for pb.Next() {
+				AsJSON(w, reason)
+			}
+		})
+	})
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			w := &discardResponseWriter{header: make(http.Header)}
+			for pb.Next() {
+				AsJSONFast(w, reason)
+			}
+		})
+	})
+}
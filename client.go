@@ -0,0 +1,130 @@
+package httpanic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MaxErrorBodyBytes is the largest error response body AsReasonError decodes.
+// Since the body comes from the server, it is limited like request bodies are
+// by DecodeJSON, so that a server cannot exhaust the memory of its clients.
+const MaxErrorBodyBytes = 1 << 20
+
+// AsReasonError returns the Reason described by resp, if it is an error
+// response, or nil otherwise. A Reason rendered by AsJSON is reconstructed
+// from the body, with its status, explanation, code, suggestion and field
+// errors; the error it wraps has the same message as the original, but not
+// the same type. Other error responses become Reasons with only their status,
+// as do those with bodies larger than MaxErrorBodyBytes.
+//
+// The body is read, and replaced so that it may be read again. A body larger
+// than MaxErrorBodyBytes is read no further than that, and the replacement
+// reads the rest of it from the original.
+func AsReasonError(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	reason := Status(resp.StatusCode)
+	if resp.Body == nil || !jsonMediaType(resp.Header.Get("Content-Type")) {
+		return reason
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxErrorBodyBytes+1))
+	if len(body) > MaxErrorBodyBytes {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return reason
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return reason
+	}
+	decoded, err := DecodeReason(bytes.NewReader(body))
+	if err != nil || decoded.error == nil || decoded.Error() == "" {
+		return reason
+	}
+	decoded.Status = resp.StatusCode
+	return decoded
+}
+
+// errNoReason is returned by DecodeReason for a JSON null.
+var errNoReason = errors.New("httpanic: no Reason in JSON null")
+
+// DecodeReason reads a Reason rendered by AsJSON from r, as it is unmarshaled
+// by its UnmarshalJSON method. A JSON null describes no Reason at all, so it is
+// an error.
+func DecodeReason(r io.Reader) (Reason, error) {
+	var reason Reason
+	if err := json.NewDecoder(r).Decode(&reason); err != nil {
+		return Reason{}, err
+	}
+	if reason.error == nil {
+		return Reason{}, errNoReason
+	}
+	return reason, nil
+}
+
+// jsonMediaType reports whether contentType is JSON, as it is for the
+// JSON-based Renderers.
+func jsonMediaType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// CheckResponse converts an error response to an error, as AsReasonError
+// describes, so that clients of servers using this package can recover the
+// Reasons they rendered. It is meant to wrap a call to http.Client.Do or one
+// of its relatives:
+//
+//	resp, err := httpanic.CheckResponse(client.Do(req))
+//
+// If err is not nil, resp and err are returned as they are. If resp is an
+// error response, its body is closed, and nil is returned with the Reason.
+// Otherwise, resp is returned.
+func CheckResponse(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return resp, err
+	}
+	if err := AsReasonError(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reasonTransport is the http.RoundTripper returned by NewReasonTransport.
+type reasonTransport struct {
+	base http.RoundTripper
+}
+
+func (t reasonTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return CheckResponse(t.base.RoundTrip(req))
+}
+
+// NewReasonTransport returns an http.RoundTripper which makes requests with
+// base, and returns error responses as errors, converted to Reasons by
+// AsReasonError. This lets clients of servers using this package recover the
+// Reasons they rendered. If base is nil, http.DefaultTransport is used.
+//
+// http.Client wraps errors from its Transport in a *url.Error, so use
+// errors.As to retrieve the Reason.
+//
+// Returning an error for a response it obtained breaks the contract of
+// http.RoundTripper, which must return a nil error whenever it obtains a
+// response, whatever its status. Other RoundTrippers stacked on top of it, like
+// those which retry or log requests, may misbehave as a result, so it should
+// be the outermost. Prefer CheckResponse, which converts error responses after
+// the http.Client is done with them.
+func NewReasonTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return reasonTransport{base: base}
+}
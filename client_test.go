@@ -0,0 +1,184 @@
+package httpanic
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// equateDecodedReasons compares Reasons decoded from responses, whose errors
+// have the same messages as the originals, but are not the same errors.
+var equateDecodedReasons = cmp.Options{
+	cmp.AllowUnexported(Reason{}),
+	cmp.FilterValues(func(x, y interface{}) bool {
+		_, xe := x.(error)
+		_, ye := y.(error)
+		_, xr := x.(Reason)
+		_, yr := y.(Reason)
+		return xe && ye && !xr && !yr
+	}, cmp.Comparer(func(x, y interface{}) bool {
+		return x.(error).Error() == y.(error).Error()
+	})),
+}
+
+func TestNewReasonTransport(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		p    interface{}
+		want Reason
+	}{
+		"json": {
+			p: Because(errors.New("widget not found"),
+				WithStatus(http.StatusNotFound),
+				WithExplanation("No widget by that name."),
+				WithCode("widget_not_found"),
+				WithFieldErrors(FieldError{Field: "name", Message: "is unknown"})),
+			want: Because(errors.New("widget not found"),
+				WithStatus(http.StatusNotFound),
+				WithExplanation("No widget by that name."),
+				WithCode("widget_not_found"),
+				WithFieldErrors(FieldError{Field: "name", Message: "is unknown"})),
+		},
+		"string": {
+			p:    "this is a string",
+			want: Because(errors.New("this is a string")),
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			srv := httptest.NewServer(GracefullyRender(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tc.p)
+			}), AsJSON))
+			defer srv.Close()
+			client := &http.Client{Transport: NewReasonTransport(nil)}
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("NewReasonTransport(): got no error")
+			}
+			var got Reason
+			if !errors.As(err, &got) {
+				t.Fatalf("NewReasonTransport(): error is not a Reason: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, equateDecodedReasons); diff != "" {
+				t.Errorf("NewReasonTransport(): mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestAsReasonErrorTooLarge(t *testing.T) {
+	body := `{"error":"` + strings.Repeat("x", MaxErrorBodyBytes) + `"}`
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	rec.WriteHeader(http.StatusBadGateway)
+	rec.WriteString(body)
+	resp := rec.Result()
+	if diff := cmp.Diff(Status(http.StatusBadGateway), AsReasonError(resp), equateDecodedReasons); diff != "" {
+		t.Errorf("AsReasonError(): mismatch (-want +got):\n%v", diff)
+	}
+	reread, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("AsReasonError(): body cannot be read again: %v", err)
+	}
+	if string(reread) != body {
+		t.Errorf("AsReasonError(): body read again has %d bytes, want: %d", len(reread), len(body))
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	srv := httptest.NewServer(GracefullyRender(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			io.WriteString(w, "Looks good!")
+			return
+		}
+		panic(Because(errors.New("widget not found"), WithStatus(http.StatusNotFound), WithCode("widget_not_found")))
+	}), AsJSON))
+	defer srv.Close()
+
+	resp, err := CheckResponse(http.Get(srv.URL + "/widgets/1"))
+	if resp != nil {
+		t.Errorf("CheckResponse(): got a response for an error: %v", resp.Status)
+	}
+	want := Because(errors.New("widget not found"), WithStatus(http.StatusNotFound), WithCode("widget_not_found"))
+	var got Reason
+	if !errors.As(err, &got) {
+		t.Fatalf("CheckResponse(): error is not a Reason: %v", err)
+	}
+	if diff := cmp.Diff(want, got, equateDecodedReasons); diff != "" {
+		t.Errorf("CheckResponse(): mismatch (-want +got):\n%v", diff)
+	}
+
+	resp, err = CheckResponse(http.Get(srv.URL + "/ok"))
+	if err != nil {
+		t.Fatalf("CheckResponse(): unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "Looks good!" {
+		t.Errorf("CheckResponse(): body got: %q, want: %q", body, "Looks good!")
+	}
+
+	errDial := errors.New("connection refused")
+	if _, err := CheckResponse(nil, errDial); err != errDial {
+		t.Errorf("CheckResponse(): error got: %v, want: %v", err, errDial)
+	}
+}
+
+func TestAsReasonError(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		render     Renderer
+		status     int
+		want       error
+		wantReread string
+	}{
+		"success": {
+			render: func(w http.ResponseWriter, _ Reason) {
+				w.Write([]byte("hello\n"))
+			},
+			status:     http.StatusOK,
+			wantReread: "hello\n",
+		},
+		"not json": {
+			render:     AsText,
+			status:     http.StatusBadGateway,
+			want:       Status(http.StatusBadGateway),
+			wantReread: "rut-ro raggy\n",
+		},
+		"json": {
+			render:     AsJSON,
+			status:     http.StatusBadGateway,
+			want:       Because(errForTesting, WithStatus(http.StatusBadGateway)),
+			wantReread: `{"error":"rut-ro raggy","status":502}` + "\n",
+		},
+		"json null": {
+			render: func(w http.ResponseWriter, _ Reason) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("null"))
+			},
+			status:     http.StatusBadRequest,
+			want:       Status(http.StatusBadRequest),
+			wantReread: "null",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, Because(errForTesting, WithStatus(tc.status)))
+			resp := rec.Result()
+			got := AsReasonError(resp)
+			if diff := cmp.Diff(tc.want, got, equateDecodedReasons); diff != "" {
+				t.Errorf("AsReasonError(): mismatch (-want +got):\n%v", diff)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("AsReasonError(): body cannot be read again: %v", err)
+			}
+			if string(body) != tc.wantReread {
+				t.Errorf("AsReasonError(): body read again got: %q, want: %q", body, tc.wantReread)
+			}
+		})
+	}
+}
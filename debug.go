@@ -0,0 +1,80 @@
+package httpanic
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Debug enables debug enrichment of rendered Reasons, such as the chain of
+// errors which caused them, and the version of Go serving responses with 5xx
+// statuses. It exposes internal details to clients, so it should only be
+// enabled during development. Set it before serving any requests.
+var Debug = false
+
+var (
+	// redactedDebugMu guards redactedDebug.
+	redactedDebugMu sync.RWMutex
+
+	// redactedDebug statuses registered using RedactDebugFor.
+	redactedDebug = map[int]bool{}
+)
+
+// RedactDebugFor ensures that Reasons with any of the listed statuses are never
+// rendered with debug enrichment, such as the chain of errors which caused
+// them or the Source of the panic, even when Debug is enabled or DebugParam
+// asks for it. It is for statuses like 401 Unauthorized and 403 Forbidden,
+// whose responses must not reveal anything beyond what the Reason says
+// outright. Statuses are added to those already listed.
+func RedactDebugFor(statuses ...int) {
+	redactedDebugMu.Lock()
+	defer redactedDebugMu.Unlock()
+	for _, status := range statuses {
+		redactedDebug[status] = true
+	}
+}
+
+// debugging reports whether the Reason should be rendered with debug
+// enrichment.
+func (r Reason) debugging() bool {
+	if !Debug && !r.debug {
+		return false
+	}
+	redactedDebugMu.RLock()
+	defer redactedDebugMu.RUnlock()
+	return !redactedDebug[r.Status]
+}
+
+// DebugParam returns a RequestRenderer which renders each Reason with render,
+// with debug enrichment enabled as if by Debug, if the query parameter named
+// param is set to a true value, like "1" or "true", and allow reports that the
+// request may have it. Since the parameter is chosen by the client, allow must
+// decide on something the server controls, like a flag set only in staging or
+// the authenticated identity of the caller, so that the parameter cannot be
+// used to reveal internal details in production. The Host header, like the
+// rest of the request, is chosen by the client, and must not be trusted for
+// this. If allow is nil, the parameter is never honored, so that forgetting
+// to decide fails safe. During development, pass a function which always
+// reports true.
+func DebugParam(param string, render Renderer, allow func(*http.Request) bool) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		if on, _ := strconv.ParseBool(r.URL.Query().Get(param)); on && allow != nil && allow(r) {
+			reason.debug = true
+		}
+		render(w, reason)
+	}
+}
+
+// causes returns the messages of the errors wrapped by the Reason's error,
+// followed by its Cause and the errors that wraps, outermost first.
+func (r Reason) causes() []string {
+	var msgs []string
+	for e := errors.Unwrap(r.error); e != nil; e = errors.Unwrap(e) {
+		msgs = append(msgs, e.Error())
+	}
+	for e := r.Cause; e != nil; e = errors.Unwrap(e) {
+		msgs = append(msgs, e.Error())
+	}
+	return msgs
+}
@@ -0,0 +1,228 @@
+package httpanic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+// withDebug runs f with Debug set to debug, restoring it afterward.
+func withDebug(t *testing.T, debug bool, f func()) {
+	t.Helper()
+	was := Debug
+	Debug = debug
+	defer func() { Debug = was }()
+	f()
+}
+
+func TestAsTextDebugCauses(t *testing.T) {
+	errRoot := errors.New("connection refused")
+	errQuery := fmt.Errorf("querying widgets: %w", errRoot)
+	reason := Because(fmt.Errorf("loading widget: %w", errQuery),
+		WithStatus(http.StatusBadGateway),
+		WithExplanation("Try again later."),
+		WithCause(fmt.Errorf("dialing db: %w", errors.New("no route to host"))))
+
+	for tn, tc := range map[string]struct {
+		debug bool
+		want  string
+	}{
+		"production": {
+			want: "loading widget: querying widgets: connection refused: Try again later.\n",
+		},
+		"debug": {
+			debug: true,
+			want: "loading widget: querying widgets: connection refused: Try again later.\n" +
+				"  caused by: querying widgets: connection refused\n" +
+				"  caused by: connection refused\n" +
+				"  caused by: dialing db: no route to host\n" +
+				"  caused by: no route to host\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			withDebug(t, tc.debug, func() {
+				AsText(rec, reason)
+			})
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsText():\n got:%q\nwant:%q\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsJSONRequestDebug(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		debug bool
+		want  string
+	}{
+		"production": {
+			want: `{"error":"rut-ro raggy","status":404}` + "\n",
+		},
+		"debug": {
+			debug: true,
+			want:  `{"error":"rut-ro raggy","status":404,"method":"DELETE","path":"/widgets/1"}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			withDebug(t, tc.debug, func() {
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodDelete, "/widgets/1?force=true", nil)
+				AsJSONRequest(rec, req, Because(errForTesting, WithStatus(http.StatusNotFound)))
+				if got := rec.Body.String(); got != tc.want {
+					t.Errorf("AsJSONRequest():\n got:%v\nwant:%v\n", got, tc.want)
+				}
+			})
+		})
+	}
+}
+
+func TestDebugParam(t *testing.T) {
+	reason := Because(fmt.Errorf("loading widget: %w", errors.New("connection refused")),
+		WithStatus(http.StatusBadGateway))
+	production := "loading widget: connection refused\n"
+	debug := production + "  caused by: connection refused\n"
+	staging := func(*http.Request) bool { return true }
+	inProduction := func(*http.Request) bool { return false }
+	for tn, tc := range map[string]struct {
+		target string
+		host   string
+		allow  func(*http.Request) bool
+		want   string
+	}{
+		"no param": {
+			target: "http://example.com/widgets/1",
+			want:   production,
+		},
+		"param true": {
+			target: "http://example.com/widgets/1?debug=1",
+			allow:  staging,
+			want:   debug,
+		},
+		"param true without allow": {
+			target: "http://example.com/widgets/1?debug=1",
+			want:   production,
+		},
+		"param false": {
+			target: "http://example.com/widgets/1?debug=0",
+			want:   production,
+		},
+		"param empty": {
+			target: "http://example.com/widgets/1?debug",
+			want:   production,
+		},
+		"allowed": {
+			target: "http://staging.example.com:8080/widgets/1?debug=true",
+			allow:  staging,
+			want:   debug,
+		},
+		"not allowed": {
+			target: "http://example.com/widgets/1?debug=true",
+			allow:  inProduction,
+			want:   production,
+		},
+		"not allowed despite host": {
+			target: "http://example.com/widgets/1?debug=true",
+			host:   "localhost",
+			allow:  inProduction,
+			want:   production,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+			DebugParam("debug", AsText, tc.allow)(rec, req, reason)
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("DebugParam():\n got:%q\nwant:%q\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRedactDebugFor(t *testing.T) {
+	RedactDebugFor(http.StatusUnauthorized, http.StatusForbidden)
+	t.Cleanup(func() {
+		redactedDebugMu.Lock()
+		defer redactedDebugMu.Unlock()
+		delete(redactedDebug, http.StatusUnauthorized)
+		delete(redactedDebug, http.StatusForbidden)
+	})
+	errToken := fmt.Errorf("verifying token: %w", errors.New("signature invalid"))
+	for tn, tc := range map[string]struct {
+		status int
+		want   string
+	}{
+		"redacted": {
+			status: http.StatusUnauthorized,
+			want:   "verifying token: signature invalid\n",
+		},
+		"not redacted": {
+			status: http.StatusInternalServerError,
+			want: "verifying token: signature invalid\n" +
+				"  caused by: signature invalid\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			withDebug(t, true, func() {
+				AsText(rec, Because(errToken, WithStatus(tc.status)))
+			})
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsText():\n got:%q\nwant:%q\n", got, tc.want)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/widgets?debug=1", nil)
+	forbidden := Because(errForTesting, WithStatus(http.StatusForbidden))
+	forbidden.Source = "widgets.Delete"
+	DebugParam("debug", AsJSON, func(*http.Request) bool { return true })(rec, req, forbidden)
+	if got, want := rec.Body.String(), `{"error":"rut-ro raggy","status":403}`+"\n"; got != want {
+		t.Errorf("DebugParam():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestGoVersionDebug(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		debug  bool
+		status int
+		want   string
+	}{
+		"debug server error": {
+			debug:  true,
+			status: http.StatusBadGateway,
+			want:   `{"error":"rut-ro raggy","status":502,"go_version":"` + runtime.Version() + `"}` + "\n",
+		},
+		"debug client error": {
+			debug:  true,
+			status: http.StatusNotFound,
+			want:   `{"error":"rut-ro raggy","status":404}` + "\n",
+		},
+		"production server error": {
+			status: http.StatusBadGateway,
+			want:   `{"error":"rut-ro raggy","status":502}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			withDebug(t, tc.debug, func() {
+				for rn, render := range map[string]Renderer{
+					"AsJSON":     AsJSON,
+					"AsJSONFast": AsJSONFast,
+				} {
+					rec := httptest.NewRecorder()
+					render(rec, Because(errForTesting, WithStatus(tc.status)))
+					if got := rec.Body.String(); got != tc.want {
+						t.Errorf("%v:\n got:%v\nwant:%v\n", rn, got, tc.want)
+					}
+				}
+			})
+		})
+	}
+}
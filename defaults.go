@@ -0,0 +1,128 @@
+package httpanic
+
+import "net/http"
+
+// WithDefaults returns middleware which applies deets as defaults to any
+// Reason to panic from the handlers it wraps, so that a group of routes can
+// share them. It must itself be wrapped by one of the Gracefully functions,
+// which recovers from the panic and renders the Reason; WithDefaults does not
+// recover from anything itself, so the panic reaches that middleware exactly as
+// it began, and Recovered reports the original value.
+//
+// Reasons given to panic keep any field which was explicitly set, meaning any
+// with a non-zero value; the status of a Reason is never zero, so it is always
+// kept. Errors and strings given to panic are first converted to Reasons by the
+// Reasoner of the middleware, and keep whatever it set in the same way, so
+// that BecauseFS still answers fs.ErrNotExist with 404 Not Found, for example.
+// Only their status is treated differently: 500 Internal Server Error, which
+// Because gives any error it knows nothing about, is replaced by a default
+// status. Headers and Metadata are merged, keeping the explicitly set values of
+// any keys in both. Runtime errors are left as they are. Where WithDefaults is
+// nested, the defaults of inner layers take precedence over those of outer
+// layers.
+func WithDefaults(deets ...Detail) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if state := stateOf(r); state != nil {
+				state.mu.Lock()
+				state.defaults = append(state.defaults, deets)
+				state.mu.Unlock()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// applyDefaults returns the Reason with the defaults of each layer of
+// WithDefaults serving the request applied, innermost first, so that those of
+// inner layers win. It is safe to call on a nil *renderState.
+func (s *renderState) applyDefaults(reason Reason) Reason {
+	if s == nil {
+		return reason
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.defaults) - 1; i >= 0; i-- {
+		var d Reason
+		for _, deet := range s.defaults[i] {
+			deet(&d)
+		}
+		reason = reason.withDefaults(d)
+	}
+	return reason
+}
+
+// applyConvertedDefaults behaves like applyDefaults, for a Reason converted
+// from an error or string by a Reasoner. Since a Reasoner always sets a status,
+// 500 Internal Server Error, which Because gives an error it knows nothing
+// about, is taken to mean that the Reasoner did not choose one, and is replaced
+// by any default status.
+func (s *renderState) applyConvertedDefaults(reason Reason) Reason {
+	if reason.Status == http.StatusInternalServerError {
+		reason.Status = 0
+	}
+	reason = s.applyDefaults(reason)
+	if reason.Status == 0 {
+		reason.Status = http.StatusInternalServerError
+	}
+	return reason
+}
+
+// withDefaults returns a clone of the Reason, with each of its zero fields set
+// to that of d. Headers and Metadata are merged.
+func (r Reason) withDefaults(d Reason) Reason {
+	r = r.Clone()
+	if r.Status == 0 {
+		r.Status = d.Status
+	}
+	if r.Explanation == "" {
+		r.Explanation = d.Explanation
+	}
+	if r.Suggestion == "" {
+		r.Suggestion = d.Suggestion
+	}
+	if r.Code == "" {
+		r.Code = d.Code
+	}
+	if r.Title == "" {
+		r.Title = d.Title
+	}
+	if r.Body == nil {
+		r.Body, r.ContentType = d.Body, d.ContentType
+	}
+	if r.Instance == "" {
+		r.Instance = d.Instance
+	}
+	if len(r.FieldErrors) == 0 {
+		r.FieldErrors = d.FieldErrors
+	}
+	if len(r.SubProblems) == 0 {
+		r.SubProblems = d.SubProblems
+	}
+	if r.Cause == nil {
+		r.Cause = d.Cause
+	}
+	if r.Render == nil {
+		r.Render = d.Render
+	}
+	if r.statusFunc == nil {
+		r.statusFunc = d.statusFunc
+	}
+	for k, vs := range d.Headers {
+		if _, ok := r.Headers[k]; !ok {
+			if r.Headers == nil {
+				r.Headers = make(http.Header)
+			}
+			r.Headers[k] = vs
+		}
+	}
+	for k, v := range d.Metadata {
+		if _, ok := r.Metadata[k]; !ok {
+			if r.Metadata == nil {
+				r.Metadata = make(map[string]interface{})
+			}
+			r.Metadata[k] = v
+		}
+	}
+	return r
+}
@@ -0,0 +1,213 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithDefaults(t *testing.T) {
+	upstream := WithDefaults(
+		WithStatus(http.StatusBadGateway),
+		WithExplanation("The widget service is unavailable."),
+		WithHeader("Retry-After", "120"))
+	for tn, tc := range map[string]struct {
+		p               interface{}
+		wantStatus      int
+		wantExplanation string
+		wantRetryAfter  string
+	}{
+		"bare error": {
+			p:               errForTesting,
+			wantStatus:      http.StatusBadGateway,
+			wantExplanation: "The widget service is unavailable.",
+			wantRetryAfter:  "120",
+		},
+		"string": {
+			p:               "this is a string",
+			wantStatus:      http.StatusBadGateway,
+			wantExplanation: "The widget service is unavailable.",
+			wantRetryAfter:  "120",
+		},
+		"reason with explicit fields": {
+			p: Because(errForTesting,
+				WithStatus(http.StatusNotFound),
+				WithExplanation("No such widget."),
+				WithHeader("Retry-After", "never")),
+			wantStatus:      http.StatusNotFound,
+			wantExplanation: "No such widget.",
+			wantRetryAfter:  "never",
+		},
+		"reason with zero fields": {
+			p:               Reason{error: errForTesting},
+			wantStatus:      http.StatusBadGateway,
+			wantExplanation: "The widget service is unavailable.",
+			wantRetryAfter:  "120",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var got Reason
+			handler := GracefullyRender(upstream(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tc.p)
+			})), func(w http.ResponseWriter, reason Reason) {
+				got = reason
+				StatusOnly(w, reason)
+			})
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("WithDefaults(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got.Explanation != tc.wantExplanation {
+				t.Errorf("WithDefaults(): explanation got: %q, want: %q", got.Explanation, tc.wantExplanation)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tc.wantRetryAfter {
+				t.Errorf("WithDefaults(): Retry-After got: %q, want: %q", got, tc.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestWithDefaultsPassesThrough(t *testing.T) {
+	original := &weirdPanic{"this would be weird"}
+	var recovered interface{}
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+		WithDefaults(WithStatus(http.StatusBadGateway))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(original)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	if recovered != original {
+		t.Errorf("WithDefaults(): re-panicked value got: %v, want the original", recovered)
+	}
+
+	var withheld Reason
+	GracefullyRender(WithDefaults(WithStatus(http.StatusBadGateway))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		var widgets map[string]int
+		widgets["sprocket"]++
+	})), func(_ http.ResponseWriter, reason Reason) {
+		withheld = reason
+	}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if withheld.clientMessage == "" {
+		t.Errorf("WithDefaults(): runtime error message not withheld from clients")
+	}
+	if errors.Unwrap(withheld) == nil {
+		t.Errorf("WithDefaults(): runtime error lost")
+	}
+}
+
+func TestWithDefaultsReasoner(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		cuz             Reasoner
+		deets           []Detail
+		p               interface{}
+		wantStatus      int
+		wantCode        string
+		wantExplanation string
+		wantRecovered   interface{}
+	}{
+		"classified error": {
+			cuz:           BecauseFS,
+			deets:         []Detail{WithCode("widgets")},
+			p:             fs.ErrNotExist,
+			wantStatus:    http.StatusNotFound,
+			wantCode:      "widgets",
+			wantRecovered: fs.ErrNotExist,
+		},
+		"classified error keeps its status": {
+			cuz:           BecauseFS,
+			deets:         []Detail{WithStatus(http.StatusBadGateway)},
+			p:             fs.ErrNotExist,
+			wantStatus:    http.StatusNotFound,
+			wantRecovered: fs.ErrNotExist,
+		},
+		"unclassified error takes the default status": {
+			cuz:           BecauseFS,
+			deets:         []Detail{WithStatus(http.StatusBadGateway), WithCode("widgets")},
+			p:             errForTesting,
+			wantStatus:    http.StatusBadGateway,
+			wantCode:      "widgets",
+			wantRecovered: errForTesting,
+		},
+		"classified error keeps its explanation": {
+			cuz:             BecauseJSON,
+			deets:           []Detail{WithStatus(http.StatusBadGateway), WithExplanation("The widget service is unavailable.")},
+			p:               &json.SyntaxError{Offset: 3},
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: BecauseJSON(&json.SyntaxError{Offset: 3}).Explanation,
+		},
+		"string": {
+			cuz:           BecauseFS,
+			deets:         []Detail{WithCode("widgets")},
+			p:             "this is a string",
+			wantStatus:    http.StatusInternalServerError,
+			wantCode:      "widgets",
+			wantRecovered: "this is a string",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var got Reason
+			handler := New(WithReasoner(tc.cuz), WithRenderer(func(w http.ResponseWriter, reason Reason) {
+				got = reason
+				StatusOnly(w, reason)
+			}))(WithDefaults(tc.deets...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tc.p)
+			})))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("WithDefaults(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got.Code != tc.wantCode {
+				t.Errorf("WithDefaults(): code got: %q, want: %q", got.Code, tc.wantCode)
+			}
+			if got.Explanation != tc.wantExplanation {
+				t.Errorf("WithDefaults(): explanation got: %q, want: %q", got.Explanation, tc.wantExplanation)
+			}
+			if tc.wantRecovered != nil && got.Recovered() != tc.wantRecovered {
+				t.Errorf("WithDefaults(): Recovered() got: %#v, want: %#v", got.Recovered(), tc.wantRecovered)
+			}
+		})
+	}
+}
+
+func TestWithDefaultsNested(t *testing.T) {
+	outer := WithDefaults(WithStatus(http.StatusBadGateway), WithCode("upstream"), WithHeader("Retry-After", "120"))
+	inner := WithDefaults(WithCode("widgets"))
+	for tn, tc := range map[string]struct {
+		p interface{}
+	}{
+		"error": {
+			p: errForTesting,
+		},
+		"reason": {
+			p: Reason{error: errForTesting},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var got Reason
+			handler := GracefullyRender(outer(inner(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tc.p)
+			}))), func(w http.ResponseWriter, reason Reason) {
+				got = reason
+				StatusOnly(w, reason)
+			})
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("WithDefaults(): status got: %v, want: %v", rec.Code, http.StatusBadGateway)
+			}
+			if got.Code != "widgets" {
+				t.Errorf("WithDefaults(): code got: %q, want: %q", got.Code, "widgets")
+			}
+			if got := rec.Header().Get("Retry-After"); got != "120" {
+				t.Errorf("WithDefaults(): Retry-After got: %q, want: %q", got, "120")
+			}
+		})
+	}
+}
@@ -30,3 +30,16 @@ func Example() {
 	}
 	log.Println(srv.ListenAndServe())
 }
+
+func ExampleServeMux() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/widgets", panickyHTTPHandler)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	srv := &http.Server{
+		// Every handler registered with mux can panic with a Reason.
+		Handler: httpanic.ServeMux(mux, httpanic.AsJSON),
+	}
+	log.Println(srv.ListenAndServe())
+}
@@ -0,0 +1,115 @@
+package httpanic
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+)
+
+// AsJSONFast renders a Reason for panicking exactly like AsJSON does, byte for
+// byte, but without the reflection of encoding/json for the common case of a
+// Reason with only an error, Code, Explanation, Suggestion and status. Reasons
+// with FieldErrors are rendered by AsJSON.
+func AsJSONFast(w http.ResponseWriter, reason Reason) {
+	if len(reason.FieldErrors) > 0 {
+		AsJSON(w, reason)
+		return
+	}
+	if prelude(w, reason) {
+		return
+	}
+	jr := reason.jsonWith(errorString)
+	respond(w, reason.Status, "application/json; charset=utf-8", func(b *bytes.Buffer) error {
+		b.WriteString(`{"error":`)
+		writeJSONString(b, jr.Error)
+		if jr.Code != "" {
+			b.WriteString(`,"code":`)
+			writeJSONString(b, jr.Code)
+		}
+		if jr.Explanation != "" {
+			b.WriteString(`,"explanation":`)
+			writeJSONString(b, jr.Explanation)
+		}
+		if jr.Suggestion != "" {
+			b.WriteString(`,"suggestion":`)
+			writeJSONString(b, jr.Suggestion)
+		}
+		if jr.Status != 0 {
+			var scratch [20]byte
+			b.WriteString(`,"status":`)
+			b.Write(strconv.AppendInt(scratch[:0], int64(jr.Status), 10))
+		}
+		if jr.Source != "" {
+			b.WriteString(`,"source":`)
+			writeJSONString(b, jr.Source)
+		}
+		if jr.GoVersion != "" {
+			b.WriteString(`,"go_version":`)
+			writeJSONString(b, jr.GoVersion)
+		}
+		b.WriteString("}\n")
+		return nil
+	})
+}
+
+// jsonEscapes holds the escaped form of each string which encoding/json
+// escapes: ASCII characters, invalid UTF-8, U+2028 and U+2029. Their escapes
+// have changed between Go versions, so they are taken from encoding/json
+// itself rather than hard coded.
+var jsonEscapes = func() (e struct {
+	ascii        [utf8.RuneSelf]string
+	invalid      string
+	lineSep      string
+	paragraphSep string
+}) {
+	escape := func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b[1 : len(b)-1])
+	}
+	for c := range e.ascii {
+		if s := string(rune(c)); escape(s) != s {
+			e.ascii[c] = escape(s)
+		}
+	}
+	e.invalid = escape("\xff")
+	e.lineSep = escape("\u2028")
+	e.paragraphSep = escape("\u2029")
+	return e
+}()
+
+// writeJSONString writes s to b as a JSON string, escaped exactly as
+// json.Encoder escapes it by default.
+func writeJSONString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		var (
+			escaped string
+			size    = 1
+		)
+		if c := s[i]; c < utf8.RuneSelf {
+			escaped = jsonEscapes.ascii[c]
+		} else {
+			var r rune
+			r, size = utf8.DecodeRuneInString(s[i:])
+			switch {
+			case r == utf8.RuneError && size == 1:
+				escaped = jsonEscapes.invalid
+			case r == '\u2028':
+				escaped = jsonEscapes.lineSep
+			case r == '\u2029':
+				escaped = jsonEscapes.paragraphSep
+			}
+		}
+		if escaped != "" {
+			b.WriteString(s[start:i])
+			b.WriteString(escaped)
+			start = i + size
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+	b.WriteByte('"')
+}
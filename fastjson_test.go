@@ -0,0 +1,59 @@
+package httpanic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAsJSONFast(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+	}{
+		"error only": {
+			reason: Because(errors.New("this is an error")),
+		},
+		"explanation": {
+			reason: Because(errors.New("this is an error"), WithStatus(420), WithExplanation("Chill, man!")),
+		},
+		"suggestion": {
+			reason: Because(errors.New("this is an error"), WithExplanation("Chill, man!"), WithSuggestion("Try again <later>.")),
+		},
+		"code": {
+			reason: Because(errors.New("this is an error"), WithCode("widget_missing\n")),
+		},
+		"empty error": {
+			reason: Because(errors.New(""), WithStatus(http.StatusNotFound)),
+		},
+		"escaping": {
+			reason: Because(errors.New("\"quoted\"\\ <b>&</b>\n\r\t\b\f\x00\x1f"),
+				WithExplanation("ümlauts \u2028 \u2029 \xff")),
+		},
+		"field errors": {
+			reason: Because(errors.New("invalid widget"),
+				WithFieldErrors(FieldError{Field: "name", Message: "is required"})),
+		},
+		"verbatim body": {
+			reason: Because(errors.New("this is an error"), WithBodyType("text/plain", []byte("verbatim"))),
+		},
+		"no content": {
+			reason: NoContent(),
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			want, got := httptest.NewRecorder(), httptest.NewRecorder()
+			AsJSON(want, tc.reason)
+			AsJSONFast(got, tc.reason)
+			if got.Code != want.Code {
+				t.Errorf("AsJSONFast(): status got: %v, want: %v", got.Code, want.Code)
+			}
+			if g, w := got.Header().Get("Content-Length"), want.Header().Get("Content-Length"); g != w {
+				t.Errorf("AsJSONFast(): Content-Length got: %q, want: %q", g, w)
+			}
+			if g, w := got.Body.String(), want.Body.String(); g != w {
+				t.Errorf("AsJSONFast():\n got:%v\nwant:%v\n", g, w)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+//go:build go1.18
+// +build go1.18
+
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// The fuzz targets need testing.F, which was added in Go 1.18.
+
+func FuzzAsJSON(f *testing.F) {
+	f.Add("this is an error", "Chill, man!")
+	f.Add("", "")
+	f.Add("\"quoted\"\\ <b>&</b>", "\n\r\t\b\f\x00\x1f")
+	f.Add("ümlauts \u2028 \u2029", "\xff\xfe")
+	f.Fuzz(func(t *testing.T, msg, explanation string) {
+		reason := Because(errors.New(msg), WithStatus(http.StatusBadRequest), WithExplanation(explanation))
+		for name, render := range map[string]Renderer{
+			"AsJSON":     AsJSON,
+			"AsJSONFast": AsJSONFast,
+		} {
+			rec := httptest.NewRecorder()
+			render(rec, reason)
+			body := rec.Body.Bytes()
+			if !json.Valid(body) {
+				t.Fatalf("%v(): invalid JSON: %q", name, body)
+			}
+			var got jsonReason
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("%v(): unexpected error unmarshaling %q: %v", name, body, err)
+			}
+			// Invalid UTF-8 is replaced when encoded, just as it is when
+			// converted to runes.
+			want := jsonReason{
+				Error:       string([]rune(msg)),
+				Explanation: string([]rune(explanation)),
+				Status:      http.StatusBadRequest,
+			}
+			if msg == "" {
+				want.Error = http.StatusText(http.StatusBadRequest)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("%v(): round trip mismatch (-want +got):\n%v", name, diff)
+			}
+		}
+	})
+}
+
+func FuzzAsJSONFast(f *testing.F) {
+	f.Add("this is an error", "Chill, man!", "Try again later.")
+	f.Add("", "", "")
+	f.Add("\"quoted\"\\ <b>&</b>", "\n\r\t\b\f\x00\x1f", "")
+	f.Add("ümlauts \u2028 \u2029", "\xff\xfe", "\u2028")
+	f.Fuzz(func(t *testing.T, msg, explanation, suggestion string) {
+		reason := Because(errors.New(msg),
+			WithStatus(http.StatusBadRequest),
+			WithExplanation(explanation),
+			WithSuggestion(suggestion))
+		want, got := httptest.NewRecorder(), httptest.NewRecorder()
+		AsJSON(want, reason)
+		AsJSONFast(got, reason)
+		if g, w := got.Body.String(), want.Body.String(); g != w {
+			t.Errorf("AsJSONFast():\n got:%q\nwant:%q\n", g, w)
+		}
+	})
+}
@@ -1,5 +1,5 @@
 module github.com/cfunkhouser/httpanic
 
-go 1.15
+go 1.16
 
-require github.com/google/go-cmp v0.5.4
+require github.com/google/go-cmp v0.5.9
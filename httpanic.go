@@ -3,12 +3,27 @@
 package httpanic
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 // Reason to panic from inside a HTTP handler.
+//
+// A Reason may be shared, and rendered for many requests at once: the built-in
+// Renderers only read its Headers, FieldErrors and Body. Details must be
+// applied before a Reason is shared, though, since they modify it in place. To
+// vary a shared Reason for one request, use Clone or the WithHeader method.
 type Reason struct {
 	error
 
@@ -18,24 +33,347 @@ type Reason struct {
 
 	// Explanation about why we decided to panic.
 	Explanation string
+
+	// Code is an application-specific error code, for clients to branch on.
+	Code string
+
+	// Suggestion to the client about what to do about it.
+	Suggestion string
+
+	// Title is a short summary of the problem, for the Renderers which have
+	// one. If it is empty, the text for Status is used.
+	Title string
+
+	// Body, if not nil, is sent to the client verbatim by the built-in
+	// Renderers instead of a body they would render themselves.
+	Body []byte
+
+	// ContentType of Body. If it is empty, the built-in Renderers detect it
+	// from Body using http.DetectContentType.
+	ContentType string
+
+	// Headers set on the response by the built-in Renderers.
+	Headers http.Header
+
+	// Instance is a URI reference identifying the specific occurrence of the
+	// problem, used by the Problem Details renderers.
+	Instance string
+
+	// FieldErrors describe problems with individual fields of the request,
+	// typically found while validating it.
+	FieldErrors []FieldError
+
+	// Metadata holds extra members to present to the client, for Renderers
+	// which support them. Values must be marshalable to JSON.
+	Metadata map[string]interface{}
+
+	// SubProblems are further Reasons, each describing one of several
+	// failures, as for a batch of items of which some could not be processed.
+	// They are presented to the client by the Renderers which support them.
+	SubProblems []Reason
+
+	// Cause is the underlying error which led to the panic, if it is distinct
+	// from the error presented to the client. It is never sent to the client.
+	Cause error
+
+	// Source is the name of the function which panicked, recorded by this
+	// package's middleware for triage. It is only sent to the client in Debug
+	// mode.
+	Source string
+
+	// Render, if set, renders the Reason in place of the Renderer of the
+	// middleware which recovers it.
+	Render Renderer
+
+	// statusFunc, if set, determines Status from the request being served.
+	statusFunc func(*http.Request) int
+
+	// unwrapCause causes Unwrap to return Cause instead of the primary error.
+	unwrapCause bool
+
+	// recovered is the value given to panic, if the Reason was recovered.
+	recovered interface{}
+
+	// clientMessage, if set, is presented to the client in place of the error
+	// message, which is reserved for logging.
+	clientMessage string
+
+	// debug enables debug enrichment for this Reason, even if Debug is false.
+	debug bool
+
+	// logged is set by MarkLogged once the Reason has been logged.
+	logged bool
+}
+
+// FieldError describes a problem with a single field of a request.
+type FieldError struct {
+	// Field which has the problem.
+	Field string `json:"field"`
+
+	// Message describing the problem.
+	Message string `json:"message"`
+}
+
+// jsonReason is the client-facing JSON representation of a Reason.
+type jsonReason struct {
+	Error       string       `json:"error"`
+	Code        string       `json:"code,omitempty"`
+	Explanation string       `json:"explanation,omitempty"`
+	Suggestion  string       `json:"suggestion,omitempty"`
+	Status      int          `json:"status,omitempty"`
+	FieldErrors []FieldError `json:"field_errors,omitempty"`
+
+	// Method and Path of the request, the Source of the panic, and for 5xx
+	// statuses the version of Go, included only in Debug mode.
+	Method    string `json:"method,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Source    string `json:"source,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+
+	// Version of the body format, included only by AsJSONVersioned.
+	Version string `json:"api_error_version,omitempty"`
+}
+
+// jsonWith builds the JSON representation of the Reason, using errorString to
+// produce the client-facing message from the wrapped error, unless the message
+// has been withheld from clients. If that message is empty, the text for the
+// Reason status is used in its place, so that the error member is never
+// blank.
+func (r Reason) jsonWith(errorString func(error) string) jsonReason {
+	msg := r.clientMessage
+	if msg == "" {
+		msg = errorString(r.error)
+	}
+	if msg == "" {
+		msg = statusText(r.Status)
+	}
+	jr := jsonReason{
+		Error:       msg,
+		Code:        r.Code,
+		Explanation: r.Explanation,
+		Suggestion:  r.Suggestion,
+		Status:      r.Status,
+		FieldErrors: r.FieldErrors,
+	}
+	if r.debugging() {
+		jr.Source = r.Source
+		if r.Status >= http.StatusInternalServerError {
+			jr.GoVersion = runtime.Version()
+		}
+	}
+	return jr
 }
 
 // MarshalJSON implements custom JSON marshaling for Reason.
 func (r Reason) MarshalJSON() ([]byte, error) {
-	jr := struct {
-		Error       string `json:"error"`
-		Explanation string `json:"explanation,omitempty"`
-	}{
-		Error:       r.Error(),
-		Explanation: r.Explanation,
+	return json.Marshal(r.jsonWith(errorString))
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for Reason, the inverse of
+// MarshalJSON. It reads the members written by MarshalJSON and AsJSON: error,
+// explanation, status, code, suggestion, field_errors and source. Only the
+// message of the original error survives; the Reason wraps a new error with
+// that message, so the original error type is lost, and errors.Is and errors.As
+// no longer match it. Fields which are never sent to clients, like Cause and
+// Metadata, are left empty. As is conventional, JSON null leaves the Reason as
+// it was.
+func (r *Reason) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
 	}
-	return json.Marshal(jr)
+	var jr jsonReason
+	if err := json.Unmarshal(b, &jr); err != nil {
+		return err
+	}
+	*r = Reason{
+		error:       errors.New(jr.Error),
+		Status:      jr.Status,
+		Explanation: jr.Explanation,
+		Code:        jr.Code,
+		Suggestion:  jr.Suggestion,
+		FieldErrors: jr.FieldErrors,
+		Source:      jr.Source,
+	}
+	return nil
+}
+
+// errorString is the default way of presenting an error to the client.
+func errorString(e error) string {
+	return e.Error()
 }
 
+// title returns the Title of the Reason, or the text for its status if it has
+// none.
+func (r Reason) title() string {
+	if r.Title != "" {
+		return r.Title
+	}
+	return statusText(r.Status)
+}
+
+// clientError returns the error message to present to the client, which is
+// the message of the wrapped error unless it has been withheld from clients.
+func (r Reason) clientError() string {
+	if r.clientMessage != "" {
+		return r.clientMessage
+	}
+	return r.Error()
+}
+
+// Unwrap returns the error the Reason wraps, so that errors.Is and errors.As
+// see through the Reason. By default, that is the primary error given to
+// Because. If WithUnwrapCause(true) was used, it is the Cause instead, and the
+// primary error is no longer matched.
 func (r Reason) Unwrap() error {
+	if r.unwrapCause {
+		return r.Cause
+	}
 	return r.error
 }
 
+// Describe the Reason for debugging and logging. Unlike MarshalJSON, which
+// produces the view of the Reason presented to clients, the description
+// includes every field which has been populated, including those never sent
+// to the client.
+func (r Reason) Describe() map[string]interface{} {
+	d := map[string]interface{}{
+		"status": r.Status,
+	}
+	if r.error != nil {
+		d["error"] = r.error.Error()
+		d["error_type"] = fmt.Sprintf("%T", r.error)
+	}
+	if r.Code != "" {
+		d["code"] = r.Code
+	}
+	if r.Title != "" {
+		d["title"] = r.Title
+	}
+	if r.Explanation != "" {
+		d["explanation"] = r.Explanation
+	}
+	if r.Suggestion != "" {
+		d["suggestion"] = r.Suggestion
+	}
+	if r.Body != nil {
+		d["body_length"] = len(r.Body)
+	}
+	if r.ContentType != "" {
+		d["content_type"] = r.ContentType
+	}
+	if len(r.Headers) > 0 {
+		d["headers"] = r.Headers
+	}
+	if r.Instance != "" {
+		d["instance"] = r.Instance
+	}
+	if len(r.FieldErrors) > 0 {
+		d["field_errors"] = r.FieldErrors
+	}
+	if len(r.Metadata) > 0 {
+		d["metadata"] = r.Metadata
+	}
+	if len(r.SubProblems) > 0 {
+		subs := make([]map[string]interface{}, len(r.SubProblems))
+		for i, sub := range r.SubProblems {
+			subs[i] = sub.Describe()
+		}
+		d["sub_problems"] = subs
+	}
+	if r.Cause != nil {
+		d["cause"] = r.Cause.Error()
+		d["cause_type"] = fmt.Sprintf("%T", r.Cause)
+	}
+	if r.Source != "" {
+		d["source"] = r.Source
+	}
+	if r.Render != nil {
+		d["render"] = true
+	}
+	if r.statusFunc != nil {
+		d["status_func"] = true
+	}
+	if r.unwrapCause {
+		d["unwrap_cause"] = true
+	}
+	if r.recovered != nil {
+		d["recovered_type"] = fmt.Sprintf("%T", r.recovered)
+	}
+	if r.clientMessage != "" {
+		d["client_message"] = r.clientMessage
+	}
+	if r.debug {
+		d["debug"] = true
+	}
+	if r.logged {
+		d["logged"] = true
+	}
+	return d
+}
+
+// MarkLogged returns a copy of the Reason which reports that it has been
+// logged, so that other layers which log Reasons can skip it. Since Reasons
+// are passed by value, the mark only reaches the layers given the copy; a
+// logging Renderer must pass the marked Reason on to the Renderer it wraps.
+func (r Reason) MarkLogged() Reason {
+	r.logged = true
+	return r
+}
+
+// WasLogged reports whether the Reason has been marked as logged using
+// MarkLogged.
+func (r Reason) WasLogged() bool {
+	return r.logged
+}
+
+// Recovered returns the value given to panic which the Reason was recovered
+// from by this package's middleware: a string, an error or a Reason. It is nil
+// if the Reason was not recovered from a panic.
+func (r Reason) Recovered() interface{} {
+	return r.recovered
+}
+
+// Clone returns a deep copy of the Reason, which shares none of its Headers,
+// FieldErrors, Metadata, SubProblems or Body with the original. Values in
+// Metadata are copied as they are, so any which are themselves maps, slices or
+// pointers remain shared. A Reason shared between requests can be cloned, and
+// the clone modified for one request, without racing with others.
+func (r Reason) Clone() Reason {
+	r.Headers = r.Headers.Clone()
+	if r.FieldErrors != nil {
+		r.FieldErrors = append(make([]FieldError, 0, len(r.FieldErrors)), r.FieldErrors...)
+	}
+	if r.Body != nil {
+		r.Body = append(make([]byte, 0, len(r.Body)), r.Body...)
+	}
+	if r.Metadata != nil {
+		m := make(map[string]interface{}, len(r.Metadata))
+		for k, v := range r.Metadata {
+			m[k] = v
+		}
+		r.Metadata = m
+	}
+	if r.SubProblems != nil {
+		subs := make([]Reason, len(r.SubProblems))
+		for i, sub := range r.SubProblems {
+			subs[i] = sub.Clone()
+		}
+		r.SubProblems = subs
+	}
+	return r
+}
+
+// WithHeader returns a clone of the Reason with the header added, so the
+// original is never modified.
+func (r Reason) WithHeader(key, value string) Reason {
+	r = r.Clone()
+	if r.Headers == nil {
+		r.Headers = make(http.Header)
+	}
+	r.Headers.Add(key, value)
+	return r
+}
+
 // Detail about a Reason for panicking.
 type Detail func(*Reason)
 
@@ -53,6 +391,204 @@ func WithExplanation(explanation string) Detail {
 	}
 }
 
+// Environment names the environment the server is running in, like
+// "development" or "production", for WithExplanationFor. Set it before serving
+// any requests.
+var Environment = ""
+
+// WithExplanationFor sets the explanation on the Reason to panic, like
+// WithExplanation, but only if Environment is env. Otherwise, it has no effect.
+// Giving one for each environment lets the same handler explain itself
+// verbosely in development and tersely in production.
+func WithExplanationFor(env, explanation string) Detail {
+	return func(r *Reason) {
+		if Environment == env {
+			r.Explanation = explanation
+		}
+	}
+}
+
+// WithCode sets an application-specific error code on the Reason to panic.
+func WithCode(code string) Detail {
+	return func(r *Reason) {
+		r.Code = code
+	}
+}
+
+// WithSuggestion sets a suggestion to the client about what to do about the
+// Reason to panic, as opposed to the Explanation of what went wrong.
+func WithSuggestion(suggestion string) Detail {
+	return func(r *Reason) {
+		r.Suggestion = suggestion
+	}
+}
+
+// WithBody sets a pre-rendered body on the Reason to panic, which is sent to
+// the client as-is instead of being rendered. Its content type is detected by
+// the built-in Renderers using http.DetectContentType; use WithBodyType to
+// provide it instead.
+func WithBody(body []byte) Detail {
+	return WithBodyType("", body)
+}
+
+// WithBodyType sets a pre-rendered body on the Reason to panic, which is sent
+// to the client as-is with the provided content type, instead of being
+// rendered. If contentType is empty, it is detected as it is for WithBody.
+func WithBodyType(contentType string, body []byte) Detail {
+	return func(r *Reason) {
+		r.ContentType = contentType
+		r.Body = body
+	}
+}
+
+// WithReasonRenderer sets a Renderer for the Reason to panic, which renders it
+// in place of the Renderer of the middleware which recovers it, or the one
+// given to HandleError. It is for panics which must be rendered in a
+// particular way wherever they happen, such as an HTML page for browsers in an
+// otherwise JSON API. It takes precedence over the Renderer or RequestRenderer
+// of the middleware, including any wrappers around it like LogJSON, but the
+// options given to New still apply: the Reason is logged and passed to hooks
+// as usual, and rendered by any fallback if its own Renderer panics.
+func WithReasonRenderer(render Renderer) Detail {
+	return func(r *Reason) {
+		r.Render = render
+	}
+}
+
+// WithStatusFunc sets a function which determines the HTTP status of the
+// response from the request being served, for errors which warrant different
+// statuses for different requests. Since the request is not known when the
+// Reason is created, f is called by this package's middleware just before the
+// Reason is rendered, and the status it returns replaces any other. A Renderer
+// invoked directly, outside of the middleware, will not see its effect.
+func WithStatusFunc(f func(*http.Request) int) Detail {
+	return func(r *Reason) {
+		r.statusFunc = f
+	}
+}
+
+// WithHeader adds a header to be set on the response by the built-in
+// Renderers.
+func WithHeader(key, value string) Detail {
+	return func(r *Reason) {
+		if r.Headers == nil {
+			r.Headers = make(http.Header)
+		}
+		r.Headers.Add(key, value)
+	}
+}
+
+// WithDeprecation marks the endpoint which panicked as deprecated, setting the
+// Deprecation and Sunset headers on the response, so that clients learn to
+// migrate even from its error responses. Since the time of deprecation is not
+// known, Deprecation is set to "true". Sunset is set to sunset, the time after
+// which the endpoint will stop responding, formatted as an HTTP-date.
+func WithDeprecation(sunset time.Time) Detail {
+	return func(r *Reason) {
+		if r.Headers == nil {
+			r.Headers = make(http.Header)
+		}
+		r.Headers.Set("Deprecation", "true")
+		r.Headers.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// WithInstance sets the URI reference identifying the specific occurrence of the
+// problem on the Reason to panic.
+func WithInstance(uri string) Detail {
+	return func(r *Reason) {
+		r.Instance = uri
+	}
+}
+
+// WithFieldErrors adds problems with individual fields of the request to the
+// Reason to panic.
+func WithFieldErrors(errs ...FieldError) Detail {
+	return func(r *Reason) {
+		r.FieldErrors = append(r.FieldErrors, errs...)
+	}
+}
+
+// WithMetadata adds an extra member named key, with value, to the Metadata of
+// the Reason to panic.
+func WithMetadata(key string, value interface{}) Detail {
+	return func(r *Reason) {
+		if r.Metadata == nil {
+			r.Metadata = make(map[string]interface{})
+		}
+		r.Metadata[key] = value
+	}
+}
+
+// WithTitle sets a short summary of the problem on the Reason to panic, for the
+// Renderers which have one, in place of the text for its status.
+func WithTitle(title string) Detail {
+	return func(r *Reason) {
+		r.Title = title
+	}
+}
+
+// WithSubProblem adds sub as one of the SubProblems of the Reason to panic.
+func WithSubProblem(sub Reason) Detail {
+	return func(r *Reason) {
+		r.SubProblems = append(r.SubProblems, sub)
+	}
+}
+
+// WithCause sets the underlying error which led to the panic on the Reason. It
+// is useful for keeping an internal error around for logging, while presenting
+// a different error to the client.
+func WithCause(cause error) Detail {
+	return func(r *Reason) {
+		r.Cause = cause
+	}
+}
+
+// WithUnwrapCause controls whether Unwrap returns the Cause of the Reason,
+// rather than the primary error. This determines which error chain is
+// searched by errors.Is and errors.As.
+func WithUnwrapCause(unwrap bool) Detail {
+	return func(r *Reason) {
+		r.unwrapCause = unwrap
+	}
+}
+
+// StatusCoder is implemented by errors which know the HTTP status that should
+// be served as a result of them.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// WithStatusFromError sets the status on the Reason to panic from the wrapped
+// error, if it or any error it wraps is a StatusCoder. Otherwise, the status is
+// left as it was.
+func WithStatusFromError() Detail {
+	return func(r *Reason) {
+		var sc StatusCoder
+		if r.error != nil && errors.As(r.error, &sc) {
+			r.Status = sc.StatusCode()
+		}
+	}
+}
+
+// Coder is implemented by errors which know their application-specific error
+// code.
+type Coder interface {
+	Code() string
+}
+
+// WithCodeFromError sets the Code on the Reason to panic from the wrapped
+// error, if it or any error it wraps is a Coder. Otherwise, the Code is left as
+// it was.
+func WithCodeFromError() Detail {
+	return func(r *Reason) {
+		var c Coder
+		if r.error != nil && errors.As(r.error, &c) {
+			r.Code = c.Code()
+		}
+	}
+}
+
 // Because describes the reason we are deciding to panic. Unless a specific
 // status is set using WithStatus, 500 Internal Server Error is assumed.
 func Because(e error, deets ...Detail) Reason {
@@ -66,23 +602,180 @@ func Because(e error, deets ...Detail) Reason {
 	return r
 }
 
+// Becausef describes the reason we are deciding to panic with an error
+// formatted by fmt.Errorf, and the provided status. As with fmt.Errorf, an
+// error given for the %w verb is wrapped, and can be found using errors.Is and
+// errors.As on the resulting Reason.
+func Becausef(status int, format string, args ...interface{}) Reason {
+	return Because(fmt.Errorf(format, args...), WithStatus(status))
+}
+
+// NoContent is a Reason to panic which ends the request successfully, with
+// status 204 No Content. It allows handlers to bail out early using the same
+// mechanism they use for errors. The built-in Renderers never send a body
+// with it.
+func NoContent(deets ...Detail) Reason {
+	return Status(http.StatusNoContent, deets...)
+}
+
+// Status is a Reason to panic with code as its status, and the text for code as
+// its error message, for when there is nothing more to say. The text is the
+// one registered using RegisterStatusText, if any, or the standard text.
+func Status(code int, deets ...Detail) Reason {
+	return Because(errors.New(statusText(code)),
+		append([]Detail{WithStatus(code)}, deets...)...)
+}
+
+// NotImplemented is a Reason to panic from endpoints which are not yet
+// implemented, with status 501 Not Implemented and the Explanation "not
+// implemented".
+func NotImplemented(deets ...Detail) Reason {
+	return Status(http.StatusNotImplemented,
+		append([]Detail{WithExplanation("not implemented")}, deets...)...)
+}
+
+// Stub returns an http.Handler for endpoints which are not yet implemented. It
+// panics with NotImplemented, so it must be wrapped by one of the Gracefully
+// functions. Since the handler has no code of its own to blame, the Source of
+// the Reason is the function which called Stub, where the endpoint is routed.
+func Stub() http.Handler {
+	source := callerName()
+	return http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		reason := NotImplemented()
+		reason.Source = source
+		panic(reason)
+	})
+}
+
 // Renderer of Reasons to the client. Used to present the reason for panicking
 // to the client in a custom way.
 type Renderer func(http.ResponseWriter, Reason)
 
-var defaultRenderer = func(w http.ResponseWriter, reason Reason) {
-	// Send the Reason status to the client, and nothing else.
+// RequestRenderer of Reasons to the client. Like a Renderer, but also given the
+// request which was being served when the panic happened.
+type RequestRenderer func(http.ResponseWriter, *http.Request, Reason)
+
+// IgnoreRequest adapts a Renderer to be used where a RequestRenderer is called
+// for.
+func IgnoreRequest(render Renderer) RequestRenderer {
+	return func(w http.ResponseWriter, _ *http.Request, reason Reason) {
+		render(w, reason)
+	}
+}
+
+// StatusOnly renders a Reason for panicking by sending its status to the
+// client, and nothing else.
+func StatusOnly(w http.ResponseWriter, reason Reason) {
+	if prelude(w, reason) {
+		return
+	}
 	w.WriteHeader(reason.Status)
 }
 
-// reasoner is the interface which describes how to convert an error to a
-// Reason. Because is a reasoner.
-type reasoner func(error, ...Detail) Reason
+// TextStatusRenderer renders a Reason for panicking as the text for its status,
+// like http.Error does, so that browsers show something more useful than a
+// blank page. Nothing about the Reason besides its status is revealed to the
+// client.
+func TextStatusRenderer(w http.ResponseWriter, reason Reason) {
+	if prelude(w, reason) {
+		return
+	}
+	respond(w, reason.Status, "text/plain; charset=utf-8", func(b *bytes.Buffer) error {
+		b.WriteString(statusText(reason.Status))
+		return b.WriteByte('\n')
+	})
+}
 
-// attemptToRecover invokes a Renderer to provide some useful HTTP response to a
-// panic in a HTTP handler, but only if the argument to panic is something this
-// package knows what to do with.
-func attemptToRecover(w http.ResponseWriter, render Renderer, cuz reasoner) {
+// prelude is the common start of the built-in Renderers. It sets the Headers
+// of the Reason on the response, and then sends the pre-rendered Body of the
+// Reason, if it has one. It reports whether it sent the Body, in which case
+// there is nothing left for the Renderer to do.
+func prelude(w http.ResponseWriter, reason Reason) bool {
+	h := w.Header()
+	for k, vs := range reason.Headers {
+		h[k] = append([]string(nil), vs...)
+	}
+	if reason.Body == nil {
+		return false
+	}
+	if !bodyAllowed(reason.Status) {
+		w.WriteHeader(reason.Status)
+		return true
+	}
+	contentType := reason.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(reason.Body)
+	}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(reason.Body)))
+	w.WriteHeader(reason.Status)
+	w.Write(reason.Body)
+	return true
+}
+
+// Reasoner describes how to convert an error to a Reason. Because is a
+// Reasoner.
+type Reasoner func(error, ...Detail) Reason
+
+// RequestReasoner describes how to convert an error to a Reason, when the
+// conversion depends on the request being served.
+type RequestReasoner func(*http.Request, error, ...Detail) Reason
+
+// withoutRequest adapts a Reasoner to be used where a RequestReasoner is called
+// for, by ignoring the request.
+func withoutRequest(cuz Reasoner) RequestReasoner {
+	return func(_ *http.Request, e error, deets ...Detail) Reason {
+		return cuz(e, deets...)
+	}
+}
+
+// ReasonFrom converts a value recovered from a panic to a Reason, as this
+// package's middleware does, and reports whether it could. Errors and strings
+// are converted by cuz. Runtime errors are converted too, but their messages
+// are withheld from clients, since they describe the code rather than the
+// request. Anything else, including nil, is not converted, and it is up to the
+// caller what to do with it; the middleware panics with it again.
+func ReasonFrom(recovered interface{}, cuz Reasoner) (Reason, bool) {
+	var reason Reason
+	switch v := recovered.(type) {
+	case Reason:
+		reason = v
+	case runtime.Error:
+		reason = cuz(v)
+		reason.clientMessage = http.StatusText(http.StatusInternalServerError)
+	case error:
+		reason = cuz(v)
+	case string:
+		reason = cuz(errors.New(v))
+	default:
+		return Reason{}, false
+	}
+	reason.recovered = recovered
+	return reason, true
+}
+
+// attemptToRecover invokes a RequestRenderer to provide some useful HTTP
+// response to a panic in a HTTP handler serving req, but only if the argument to
+// panic is something this package knows what to do with.
+//
+// Anything else is given to panic again exactly as it was recovered, so that
+// whatever recovers it next sees the same value, of the same type. Since the
+// deferred call runs on top of the panicking frames, the stack printed for an
+// unrecovered panic still leads to where it began. Panics in render are not
+// recovered, and propagate with the value render panicked with.
+//
+// Where req is served by layers of this package's middleware, render and cuz
+// are only used for whatever none of them configures, as described for New.
+//
+// Before rendering, the defaults of any WithDefaults layers serving req fill in
+// the fields of the Reason which were not set, whether by the code which
+// panicked or, for errors and strings, by cuz.
+//
+// A Reason is rendered at most once for each request served by this package's
+// middleware. Should a later panic be recovered for the same request, after a
+// Reason has already been rendered, the panic is dropped and nothing more is
+// sent, since the response is already on its way to the client.
+func attemptToRecover(w http.ResponseWriter, req *http.Request, render RequestRenderer, cuz RequestReasoner) {
 	r := recover()
 	// recover returns nil when:
 	//   1. It is called outside of a deferred function
@@ -93,26 +786,377 @@ func attemptToRecover(w http.ResponseWriter, render Renderer, cuz reasoner) {
 		return
 	}
 
-	switch reason := r.(type) {
+	state := stateOf(req)
+	render, cuz = state.configure(render, cuz)
+	reason, ok := ReasonFrom(r, func(e error, deets ...Detail) Reason {
+		return cuz(req, e, deets...)
+	})
+	if !ok {
+		panic(r)
+	}
+	switch r.(type) {
 	case Reason:
-		render(w, reason)
-	case error:
-		render(w, cuz(reason))
-	case string:
-		render(w, cuz(errors.New(reason)))
-	default:
-		panic(reason)
+		reason = state.applyDefaults(reason)
+	case runtime.Error:
+	case error, string:
+		reason = state.applyConvertedDefaults(reason)
+	}
+	if !claimRender(req) {
+		return
+	}
+	if reason.Source == "" {
+		reason.Source = panicSource()
+	}
+	if reason.statusFunc != nil {
+		reason.Status = reason.statusFunc(req)
 	}
+	state.observe(req, reason)
+	state.wrap(render)(w, req, reason)
 }
 
 // AsJSON renders a Reason for panicking. If any errors are encountered during
 // render, this function will panic.
 func AsJSON(w http.ResponseWriter, reason Reason) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(reason.Status)
-	if err := json.NewEncoder(w).Encode(reason); err != nil {
+	jsonRenderer{}.render(w, reason)
+}
+
+// AsJSONWithErrorFunc returns a Renderer which behaves like AsJSON, except that
+// the client-facing error message is produced by calling f with the error the
+// Reason wraps, instead of calling its Error method. This allows noisy or
+// sensitive error messages to be rewritten in one place.
+func AsJSONWithErrorFunc(f func(error) string) Renderer {
+	return jsonRenderer{errorString: f}.render
+}
+
+// AsJSONOmitStatus returns a Renderer which behaves like AsJSON, except that
+// the status is left out of the body, since it is sent in the status line
+// anyway.
+func AsJSONOmitStatus() Renderer {
+	return jsonRenderer{omitStatus: true}.render
+}
+
+// AsJSONIndented returns a Renderer which behaves like AsJSON, except that the
+// JSON is indented for people to read, as if by json.MarshalIndent with prefix
+// and indent.
+func AsJSONIndented(prefix, indent string) Renderer {
+	return jsonRenderer{prefix: prefix, indent: indent}.render
+}
+
+// AsJSONCharset returns a Renderer which behaves like AsJSON, except that the
+// Content-Type has the charset parameter set to charset instead of utf-8. If
+// charset is empty, the parameter is left out. The body is UTF-8 regardless,
+// as JSON must be.
+func AsJSONCharset(charset string) Renderer {
+	return jsonRenderer{contentType: withCharset("application/json", charset)}.render
+}
+
+// AsJSONWith returns a Renderer which behaves like AsJSON, except that the
+// json.Encoder is passed to configure before the Reason is encoded, so that
+// its settings may be changed. For example, calling SetEscapeHTML(false) stops
+// characters like "<" in error messages from being escaped as "\u003c".
+func AsJSONWith(configure func(*json.Encoder)) Renderer {
+	return jsonRenderer{configure: configure}.render
+}
+
+// AsJSONVersioned returns a Renderer which behaves like AsJSON, and
+// additionally includes version in the body as the member "api_error_version",
+// so that clients can detect changes to its format.
+func AsJSONVersioned(version string) Renderer {
+	return jsonRenderer{version: version}.render
+}
+
+// AsNDJSON renders a Reason for panicking like AsJSON, as a single line of
+// newline-delimited JSON with the Content-Type application/x-ndjson, for
+// clients which expect every response to be NDJSON. If any errors are
+// encountered during render, this function will panic.
+func AsNDJSON(w http.ResponseWriter, reason Reason) {
+	jsonRenderer{contentType: "application/x-ndjson"}.render(w, reason)
+}
+
+// withCharset returns the Content-Type for mediaType with the charset
+// parameter set to charset, or without it if charset is empty.
+func withCharset(mediaType, charset string) string {
+	if charset == "" {
+		return mediaType
+	}
+	return mediaType + "; charset=" + charset
+}
+
+// AsJSONRequest behaves like AsJSON, and additionally includes the method and
+// path of the failed request in the body, in Debug mode.
+func AsJSONRequest(w http.ResponseWriter, r *http.Request, reason Reason) {
+	jsonRenderer{}.renderRequest(w, r, reason)
+}
+
+// jsonRenderer holds the options of the AsJSON family of Renderers. Its zero
+// value renders exactly like AsJSON.
+type jsonRenderer struct {
+	// errorString produces the client-facing message from the wrapped error.
+	errorString func(error) string
+
+	// omitStatus leaves the status out of the body.
+	omitStatus bool
+
+	// prefix and indent are passed to json.Encoder.SetIndent.
+	prefix, indent string
+
+	// contentType of the body, if not "application/json; charset=utf-8".
+	contentType string
+
+	// version of the body format, if it is to be included.
+	version string
+
+	// configure, if set, is applied to the json.Encoder before encoding.
+	configure func(*json.Encoder)
+}
+
+func (j jsonRenderer) render(w http.ResponseWriter, reason Reason) {
+	j.renderRequest(w, nil, reason)
+}
+
+// renderRequest renders reason for the request r, which may be nil if it is
+// not known.
+func (j jsonRenderer) renderRequest(w http.ResponseWriter, r *http.Request, reason Reason) {
+	if prelude(w, reason) {
+		return
+	}
+	f := j.errorString
+	if f == nil {
+		f = errorString
+	}
+	jr := reason.jsonWith(f)
+	if j.omitStatus {
+		jr.Status = 0
+	}
+	if r != nil && reason.debugging() {
+		jr.Method, jr.Path = r.Method, r.URL.Path
+	}
+	jr.Version = j.version
+	contentType := j.contentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	respond(w, reason.Status, contentType, func(b *bytes.Buffer) error {
+		enc := json.NewEncoder(b)
+		enc.SetIndent(j.prefix, j.indent)
+		if j.configure != nil {
+			j.configure(enc)
+		}
+		return enc.Encode(jr)
+	})
+}
+
+// AsText renders a Reason for panicking as a single line of plain text,
+// consisting of the error message followed by the explanation, if any. In
+// Debug mode, each error in the chain of causes follows on its own line.
+func AsText(w http.ResponseWriter, reason Reason) {
+	renderText(w, reason, "text/plain; charset=utf-8")
+}
+
+// AsTextCharset returns a Renderer which behaves like AsText, except that the
+// Content-Type has the charset parameter set to charset instead of utf-8. If
+// charset is empty, the parameter is left out. The body is written as it is,
+// without converting it to charset.
+func AsTextCharset(charset string) Renderer {
+	contentType := withCharset("text/plain", charset)
+	return func(w http.ResponseWriter, reason Reason) {
+		renderText(w, reason, contentType)
+	}
+}
+
+func renderText(w http.ResponseWriter, reason Reason, contentType string) {
+	if prelude(w, reason) {
+		return
+	}
+	respond(w, reason.Status, contentType, func(b *bytes.Buffer) error {
+		b.WriteString(reason.clientError())
+		if reason.Explanation != "" {
+			b.WriteString(": ")
+			b.WriteString(reason.Explanation)
+		}
+		b.WriteByte('\n')
+		if reason.debugging() {
+			for _, c := range reason.causes() {
+				b.WriteString("  caused by: ")
+				b.WriteString(c)
+				b.WriteByte('\n')
+			}
+		}
+		return nil
+	})
+}
+
+// AsEnvelopeJSON renders a Reason for panicking wrapped in a uniform response
+// envelope, like {"success":false,"error":{...}}, where the error member is the
+// same object AsJSON would render. If any errors are encountered during
+// render, this function will panic.
+func AsEnvelopeJSON(w http.ResponseWriter, reason Reason) {
+	AsEnvelopeJSONWithKeys("success", "error")(w, reason)
+}
+
+// AsEnvelopeJSONWithKeys returns a Renderer which behaves like AsEnvelopeJSON,
+// using successKey and errorKey as the names of the envelope members.
+func AsEnvelopeJSONWithKeys(successKey, errorKey string) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		if prelude(w, reason) {
+			return
+		}
+		writeJSON(w, reason.Status, map[string]interface{}{
+			successKey: false,
+			errorKey:   reason,
+		})
+	}
+}
+
+// AsTrailer returns a Renderer suitable for streaming responses. If the
+// response has not yet started, it behaves like StatusOnly. Once the status
+// line has been sent, it can no longer be changed, so the Reason status is
+// instead sent in the trailer named by statusTrailerKey. Per net/http, the
+// handler must declare that trailer before writing the response, by setting the
+// "Trailer" header to statusTrailerKey.
+func AsTrailer(statusTrailerKey string) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		if !started(w) {
+			StatusOnly(w, reason)
+			return
+		}
+		w.Header().Set(statusTrailerKey, strconv.Itoa(reason.Status))
+	}
+}
+
+// WithMaxExplanationLength wraps render, truncating the Explanation of each
+// Reason to at most n characters before it is rendered. Truncated explanations
+// end with an ellipsis, which counts toward n. If n is not positive,
+// explanations are not truncated.
+func WithMaxExplanationLength(n int, render Renderer) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		reason.Explanation = truncate(reason.Explanation, n)
+		render(w, reason)
+	}
+}
+
+// NoSniff wraps render, setting "X-Content-Type-Options: nosniff" on the
+// response so that browsers do not sniff the content type of the body. The
+// built-in Renderers already do this for the bodies they render; NoSniff is
+// for custom Renderers, and for Bodies set using WithBody.
+func NoSniff(render Renderer) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		render(w, reason)
+	}
+}
+
+// NoBodyFor wraps render, sending only the status and the Headers of the
+// Reason, with no body, for Reasons with any of the listed statuses. Reasons
+// with other statuses are rendered by render. Any Body set using WithBody is
+// withheld as well.
+func NoBodyFor(render Renderer, statuses ...int) Renderer {
+	bodiless := make(map[int]bool, len(statuses))
+	for _, status := range statuses {
+		bodiless[status] = true
+	}
+	return func(w http.ResponseWriter, reason Reason) {
+		if !bodiless[reason.Status] {
+			render(w, reason)
+			return
+		}
+		reason.Body = nil
+		StatusOnly(w, reason)
+	}
+}
+
+// ContentLengthForHTTP10 adapts render to always send Content-Length to
+// HTTP/1.0 clients, some of which cannot cope with a response of unknown
+// length. For those clients, the response is buffered so that its length is
+// known before it is sent. Other requests are rendered as they are. The
+// built-in Renderers already set Content-Length; this is for custom ones.
+func ContentLengthForHTTP10(render Renderer) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		if r.ProtoMajor != 1 || r.ProtoMinor != 0 {
+			render(w, reason)
+			return
+		}
+		b := &bufferedWriter{header: w.Header()}
+		render(b, reason)
+		b.flush(w)
+	}
+}
+
+// RenderBytes renders reason with render in memory, rather than to a client,
+// and returns the rendered status, headers and body. It is useful for testing
+// Renderers, and for embedding rendered Reasons in other responses. If render
+// writes nothing, the status is 200 OK, as it would be for net/http.
+func RenderBytes(reason Reason, render Renderer) (status int, header http.Header, body []byte) {
+	b := &bufferedWriter{header: make(http.Header)}
+	render(b, reason)
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.status, b.header, b.body.Bytes()
+}
+
+// Transform wraps render, giving f a last chance to rewrite each Reason before
+// it is rendered; to add a header, adjust the status, or remove sensitive
+// details, for example.
+func Transform(f func(Reason) Reason, render Renderer) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		render(w, f(reason))
+	}
+}
+
+// truncate s to at most n runes, replacing the last with an ellipsis if any
+// were removed. If n is not positive, s is returned unchanged.
+func truncate(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// writeJSON sends v to the client as JSON with the provided status. If any
+// errors are encountered, this function will panic.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	respond(w, status, "application/json; charset=utf-8", func(b *bytes.Buffer) error {
+		return json.NewEncoder(b).Encode(v)
+	})
+}
+
+// respond sends a response with the provided status and content type. The body
+// is produced by encode into a pooled buffer, which allows Content-Length to be
+// set and the body to be sent in a single write. If encode returns an error,
+// nothing is sent and this function will panic. If the status does not permit
+// a body, only the status is sent. Bodies are sent with
+// "X-Content-Type-Options: nosniff", so that browsers do not second-guess the
+// content type.
+func respond(w http.ResponseWriter, status int, contentType string, encode func(*bytes.Buffer) error) {
+	if !bodyAllowed(status) {
+		w.WriteHeader(status)
+		return
+	}
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := encode(b); err != nil {
 		panic(err)
 	}
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(b.Len()))
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(b.Bytes())
+}
+
+// bodyAllowed reports whether a response with status may have a body. Per RFC
+// 7230, 1xx, 204 and 304 responses must not.
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status < 200:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
 }
 
 // GracefullyRender any Reason to panic with the provided Renderer. If the panic
@@ -122,14 +1166,303 @@ func AsJSON(w http.ResponseWriter, reason Reason) {
 // function propagates the panic. If anything panics while attempting to handle
 // a panic, no attempt will be made to recover from that panic.
 func GracefullyRender(next http.Handler, render Renderer) http.Handler {
+	return GracefullyRenderRequest(next, IgnoreRequest(render))
+}
+
+// GracefullyRenderRequest behaves like GracefullyRender, for RequestRenderers.
+func GracefullyRenderRequest(next http.Handler, render RequestRenderer) http.Handler {
+	return New(WithRequestRenderer(render))(next)
+}
+
+// GracefullyReason behaves like GracefullyRenderRequest, except that errors and
+// strings given as arguments to panic are converted to Reasons by cuz, rather
+// than by Because, so that they may be classified according to the request.
+func GracefullyReason(next http.Handler, render RequestRenderer, cuz RequestReasoner) http.Handler {
+	return New(WithRequestRenderer(render), WithRequestReasoner(cuz))(next)
+}
+
+// GracefullyRenderOnSuccess behaves like GracefullyRender, and additionally
+// calls onSuccess after next has served a request without panicking. It is not
+// called when a panic occurred, whether or not it was recovered from.
+func GracefullyRenderOnSuccess(next http.Handler, render Renderer, onSuccess func(*http.Request)) http.Handler {
+	return New(WithRenderer(render), WithOnSuccess(onSuccess))(next)
+}
+
+// GracefullyIf behaves like GracefullyRender for requests for which should
+// returns true. Other requests are passed to next without any attempt to
+// recover from panics, leaving them to some other layer of recovery.
+func GracefullyIf(next http.Handler, render Renderer, should func(*http.Request) bool) http.Handler {
+	recovering := GracefullyRender(next, render)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer attemptToRecover(w, render, Because)
+		if should(r) {
+			recovering.ServeHTTP(w, r)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// middleware is the configuration shared by the ways of handling panics
+// gracefully.
+type middleware struct {
+	render    RequestRenderer
+	cuz       RequestReasoner
+	onSuccess func(*http.Request)
+	logger    *log.Logger
+	fallback  Renderer
+	onPanic   func(*http.Request, Reason)
+	after     func(*http.Request, Reason)
+	debug     bool
+}
+
+// recoveringKey is the context key marking requests which are already being
+// served by a layer of this package's middleware. Its value is the
+// *renderState of the request.
+type recoveringKey struct{}
+
+// renderState records whether a Reason has been rendered for a request, along
+// with anything inner layers, like WithDefaults, have left for the layer which
+// recovers to apply.
+type renderState struct {
+	rendered int32
+
+	mu        sync.Mutex
+	defaults  [][]Detail
+	observers []func(*http.Request, Reason)
+	wrappers  []func(RequestRenderer) RequestRenderer
+	layers    []middleware
+}
+
+// enter records that m is serving the request, within the layers already
+// serving it, and returns the number of those, for leave.
+func (s *renderState) enter(m middleware) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	depth := len(s.layers)
+	s.layers = append(s.layers, m)
+	return depth
+}
+
+// leave records that the layer which entered at depth has returned, along
+// with any within it. Layers are only left when they return, so those a panic
+// passed through remain recorded for the layer which recovers it.
+func (s *renderState) leave(depth int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.layers = s.layers[:depth]
+}
+
+// configure returns render and cuz as configured by the layers of this
+// package's middleware serving the request, as described for New. It is safe
+// to call on a nil *renderState, and returns render and cuz as they are if no
+// layers are recorded.
+func (s *renderState) configure(render RequestRenderer, cuz RequestReasoner) (RequestRenderer, RequestReasoner) {
+	if s == nil {
+		return render, cuz
+	}
+	s.mu.Lock()
+	layers := append([]middleware(nil), s.layers...)
+	s.mu.Unlock()
+	if len(layers) == 0 {
+		return render, cuz
+	}
+	return configure(layers, render, cuz)
+}
+
+// onRender arranges for the RequestRenderer of whichever layer of this
+// package's middleware recovers while serving req to be wrapped by wrap, as if
+// that layer had been configured with it. It does nothing if req is not being
+// served by this package's middleware.
+func onRender(req *http.Request, wrap func(RequestRenderer) RequestRenderer) {
+	state := stateOf(req)
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.wrappers = append(state.wrappers, wrap)
+}
+
+// wrap returns render wrapped by each of the functions given to onRender for
+// the request, the first of them outermost. It is safe to call on a nil
+// *renderState.
+func (s *renderState) wrap(render RequestRenderer) RequestRenderer {
+	if s == nil {
+		return render
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.wrappers) - 1; i >= 0; i-- {
+		render = s.wrappers[i](render)
+	}
+	return render
+}
+
+// onRecover arranges for observe to be called with the Reason recovered while
+// serving req, by whichever layer of this package's middleware recovers it,
+// once its status is settled and just before it is rendered. It lets inner
+// layers, which leave recovering to an outer layer, see what is rendered. It
+// does nothing if req is not being served by this package's middleware.
+func onRecover(req *http.Request, observe func(*http.Request, Reason)) {
+	state := stateOf(req)
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.observers = append(state.observers, observe)
+}
+
+// observe calls each of the functions given to onRecover for the request with
+// the Reason, in the order they were given. It is safe to call on a nil
+// *renderState.
+func (s *renderState) observe(req *http.Request, reason Reason) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	observers := s.observers
+	s.mu.Unlock()
+	for _, observe := range observers {
+		observe(req, reason)
+	}
+}
+
+// stateOf returns the *renderState of req, or nil if req is not being served by
+// this package's middleware.
+func stateOf(req *http.Request) *renderState {
+	if req == nil {
+		return nil
+	}
+	state, _ := req.Context().Value(recoveringKey{}).(*renderState)
+	return state
+}
+
+// claimRender reports whether a Reason may be rendered for req, which is only
+// the case the first time it is called for a request served by this package's
+// middleware. Rendering is always allowed for other requests, since there is
+// no record of it.
+func claimRender(req *http.Request) bool {
+	state := stateOf(req)
+	return state == nil || atomic.CompareAndSwapInt32(&state.rendered, 0, 1)
+}
+
+// handler wraps next with the middleware. If the request is already being
+// served by an outer layer of middleware, as when a handler is wrapped both
+// globally and for its route, this layer does not attempt to recover. Panics
+// are left to the outer layer, so that they are only rendered once, but this
+// layer is recorded as serving the request until it returns, so that the outer
+// layer renders them as configured by both.
+func (m middleware) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := stateOf(r)
+		if state == nil {
+			state = &renderState{}
+			w = trackWriter(w)
+			r = r.WithContext(context.WithValue(r.Context(), recoveringKey{}, state))
+			defer attemptToRecover(w, r, IgnoreRequest(TextStatusRenderer), withoutRequest(Because))
+		}
+		depth := state.enter(m)
+		next.ServeHTTP(w, r)
+		state.leave(depth)
+		if m.onSuccess != nil {
+			m.onSuccess(r)
+		}
+	})
+}
+
+// GracefullyRenderErrorLog behaves like GracefullyRender, and additionally logs
+// each recovered Reason to logger, in the same format net/http uses to log
+// panics it recovers from itself, including the stack of the panicking
+// goroutine. Reasons already marked as logged using MarkLogged are not logged
+// again.
+func GracefullyRenderErrorLog(next http.Handler, render Renderer, logger *log.Logger) http.Handler {
+	return New(WithRenderer(render), WithLogger(logger))(next)
+}
+
+// GracefullyRenderAfter behaves like GracefullyRender, and additionally calls
+// after once each recovered Reason has been rendered, to flush metrics or
+// release resources, for example. It is called even if render panics, in which
+// case that panic continues once after returns.
+func GracefullyRenderAfter(next http.Handler, render Renderer, after func(*http.Request, Reason)) http.Handler {
+	return New(WithRenderer(render), WithAfterRender(after))(next)
+}
+
+// ServeMux wraps every handler registered with mux at once, rendering any
+// Reason to panic with render, as GracefullyRender does. Handlers registered
+// after wrapping are covered too, since the mux itself is wrapped.
+func ServeMux(mux *http.ServeMux, render Renderer) http.Handler {
+	return GracefullyRender(mux, render)
+}
+
 // Gracefully handle any Reason to panic by returning an appropriate status
-// code, with no response body. See GracefullyRender for additional detail.
+// code, with the standard text for that status as the response body. See
+// TextStatusRenderer, and GracefullyRender for additional detail. To send no
+// body at all, use GracefullyRender with StatusOnly.
 func Gracefully(next http.Handler) http.Handler {
-	return GracefullyRender(next, defaultRenderer)
+	return GracefullyRender(next, TextStatusRenderer)
+}
+
+// GracefullyFunc behaves like GracefullyRender, for a single handler function.
+func GracefullyFunc(fn http.HandlerFunc, render Renderer) http.HandlerFunc {
+	return GracefullyRender(fn, render).ServeHTTP
+}
+
+// GracefullyFuncDefault behaves like Gracefully, for a single handler function.
+func GracefullyFuncDefault(fn http.HandlerFunc) http.HandlerFunc {
+	return Gracefully(fn).ServeHTTP
+}
+
+// HandleError adapts a handler which returns errors, rather than panicking with
+// them, to an http.Handler. When h returns a non-nil error, it is converted to
+// a Reason by cuz and rendered with render. An error which is already a Reason,
+// or wraps one, as fmt.Errorf does with %w, is rendered as that Reason. Panics
+// in h are not recovered; wrap the result with one of the Gracefully functions
+// to handle those, too. When it is, errors and panics count together toward the
+// single Reason rendered for each request.
+func HandleError(h func(http.ResponseWriter, *http.Request) error, cuz Reasoner, render Renderer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := trackWriter(w)
+		err := h(rw, r)
+		if err == nil {
+			return
+		}
+		if !claimRender(r) {
+			return
+		}
+		var reason Reason
+		if !errors.As(err, &reason) {
+			reason = cuz(err)
+		}
+		if reason.statusFunc != nil {
+			reason.Status = reason.statusFunc(r)
+		}
+		rr := render
+		if reason.Render != nil {
+			rr = reason.Render
+		}
+		rr(rw, reason)
+	})
+}
+
+// HandleStatusError adapts a handler which returns the status of the response
+// along with any error, to an http.Handler. When h returns a non-nil error, it
+// is rendered with render as the Reason Because(err, WithStatus(code)). An
+// error which is already a Reason, or wraps one, is rendered as that Reason,
+// keeping its other details, but taking code as its status. If code is zero,
+// the status is left as it would be otherwise. As with HandleError, panics in h
+// are not recovered.
+func HandleStatusError(h func(http.ResponseWriter, *http.Request) (int, error), render Renderer) http.Handler {
+	return HandleError(func(w http.ResponseWriter, r *http.Request) error {
+		code, err := h(w, r)
+		if err == nil || code == 0 {
+			return err
+		}
+		var reason Reason
+		if !errors.As(err, &reason) {
+			reason = Because(err)
+		}
+		reason.Status = code
+		return reason
+	}, Because, render)
 }
@@ -1,18 +1,28 @@
 package httpanic
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestReasonMarshalJSON(t *testing.T) {
-	want := `{"error":"this is an error","explanation":"Chill, man!"}`
+	want := `{"error":"this is an error","explanation":"Chill, man!","status":420}`
 	reason := Reason{
 		error:       errors.New("this is an error"),
 		Status:      420,
@@ -27,6 +37,859 @@ func TestReasonMarshalJSON(t *testing.T) {
 	}
 }
 
+func TestReasonMarshalJSONFieldErrors(t *testing.T) {
+	want := `{"error":"invalid widget","status":500,"field_errors":[{"field":"name","message":"is required"},{"field":"size","message":"must be positive"}]}`
+	reason := Because(errors.New("invalid widget"),
+		WithFieldErrors(FieldError{Field: "name", Message: "is required"}),
+		WithFieldErrors(FieldError{Field: "size", Message: "must be positive"}))
+	b, err := json.Marshal(reason)
+	if err != nil {
+		t.Fatalf("Reason.MarshalJSON(): unexpected error: %v", err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("Reason.MarshalJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestReasonMarshalJSONEmptyError(t *testing.T) {
+	want := `{"error":"Not Found","explanation":"Chill, man!","status":404}`
+	reason := Because(errors.New(""), WithStatus(http.StatusNotFound), WithExplanation("Chill, man!"))
+	b, err := json.Marshal(reason)
+	if err != nil {
+		t.Fatalf("Reason.MarshalJSON(): unexpected error: %v", err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("Reason.MarshalJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestTextStatusRenderer(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		status   int
+		wantBody string
+	}{
+		"not found": {
+			status:   http.StatusNotFound,
+			wantBody: "Not Found\n",
+		},
+		"internal server error": {
+			status:   http.StatusInternalServerError,
+			wantBody: "Internal Server Error\n",
+		},
+		"no content": {
+			status: http.StatusNoContent,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			TextStatusRenderer(rec, Because(errForTesting, WithStatus(tc.status), WithExplanation("Chill, man!")))
+			if rec.Code != tc.status {
+				t.Errorf("TextStatusRenderer(): status got: %v, want: %v", rec.Code, tc.status)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("TextStatusRenderer(): body got: %q, want: %q", got, tc.wantBody)
+			}
+			if tc.wantBody == "" {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+				t.Errorf("TextStatusRenderer(): Content-Type got: %q", got)
+			}
+		})
+	}
+}
+
+func TestReasonUnmarshalJSONRoundTrip(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+	}{
+		"minimal": {
+			reason: Because(errors.New("this is an error")),
+		},
+		"populated": {
+			reason: Because(errors.New("this is an error"),
+				WithStatus(http.StatusTeapot),
+				WithExplanation("Chill, man!"),
+				WithCode("chill"),
+				WithSuggestion("Take a breath."),
+				WithFieldErrors(FieldError{Field: "name", Message: "is required"})),
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			b, err := json.Marshal(tc.reason)
+			if err != nil {
+				t.Fatalf("MarshalJSON(): unexpected error: %v", err)
+			}
+			var got Reason
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("UnmarshalJSON(): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.reason, got, equateDecodedReasons); diff != "" {
+				t.Errorf("UnmarshalJSON(): mismatch (-want +got):\n%v", diff)
+			}
+			again, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("MarshalJSON(): unexpected error: %v", err)
+			}
+			if string(again) != string(b) {
+				t.Errorf("MarshalJSON(): of unmarshaled Reason got: %s, want: %s", again, b)
+			}
+		})
+	}
+
+	want := Because(errForTesting, WithStatus(http.StatusTeapot), WithExplanation("Chill, man!"))
+	got := want
+	if err := json.Unmarshal([]byte("null"), &got); err != nil {
+		t.Fatalf("UnmarshalJSON(): unexpected error for null: %v", err)
+	}
+	if diff := cmp.Diff(want, got, equateReasons); diff != "" {
+		t.Errorf("UnmarshalJSON(): null changed the Reason (-want +got):\n%v", diff)
+	}
+}
+
+func TestReasonUnmarshalJSON(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason          Reason
+		wantStatus      int
+		wantExplanation string
+		wantError       string
+	}{
+		"explained": {
+			reason: Because(&url.Error{Op: "Get", URL: "http://widgets", Err: errForTesting},
+				WithStatus(http.StatusBadGateway),
+				WithExplanation("The widget service is unavailable.")),
+			wantStatus:      http.StatusBadGateway,
+			wantExplanation: "The widget service is unavailable.",
+			wantError:       `Get "http://widgets": rut-ro raggy`,
+		},
+		"default status": {
+			reason:     Because(errForTesting),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "rut-ro raggy",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsJSON(rec, tc.reason)
+			var got Reason
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("UnmarshalJSON(): unexpected error: %v", err)
+			}
+			if got.Status != tc.wantStatus {
+				t.Errorf("UnmarshalJSON(): status got: %v, want: %v", got.Status, tc.wantStatus)
+			}
+			if got.Explanation != tc.wantExplanation {
+				t.Errorf("UnmarshalJSON(): explanation got: %q, want: %q", got.Explanation, tc.wantExplanation)
+			}
+			if got.Error() != tc.wantError {
+				t.Errorf("UnmarshalJSON(): error got: %q, want: %q", got.Error(), tc.wantError)
+			}
+			var ue *url.Error
+			if errors.As(got, &ue) {
+				t.Errorf("UnmarshalJSON(): original error type survived: %T", ue)
+			}
+		})
+	}
+	var got Reason
+	if err := json.Unmarshal([]byte(`{"error":42}`), &got); err == nil {
+		t.Errorf("UnmarshalJSON(): got no error for a non-string error member")
+	}
+}
+
+func TestDecodeReason(t *testing.T) {
+	rec := httptest.NewRecorder()
+	want := Because(errors.New("widget not found"),
+		WithStatus(http.StatusNotFound),
+		WithExplanation("No widget by that name."))
+	AsJSON(rec, want)
+	got, err := DecodeReason(rec.Body)
+	if err != nil {
+		t.Fatalf("DecodeReason(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, got, equateDecodedReasons); diff != "" {
+		t.Errorf("DecodeReason(): mismatch (-want +got):\n%v", diff)
+	}
+	if _, err := DecodeReason(strings.NewReader("not json")); err == nil {
+		t.Errorf("DecodeReason(): got no error for invalid JSON")
+	}
+	if _, err := DecodeReason(strings.NewReader("null")); err == nil {
+		t.Errorf("DecodeReason(): got no error for null")
+	}
+}
+
+func TestAsJSON(t *testing.T) {
+	want := `{"error":"this is an error","explanation":"Chill, man!","status":420}` + "\n"
+	rec := httptest.NewRecorder()
+	AsJSON(rec, Because(errors.New("this is an error"),
+		WithStatus(420),
+		WithExplanation("Chill, man!")))
+	if rec.Code != 420 {
+		t.Errorf("AsJSON(): status got: %v, want: %v", rec.Code, 420)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("AsJSON(): Content-Type got: %q", got)
+	}
+	if got, want := rec.Header().Get("Content-Length"), fmt.Sprint(len(want)); got != want {
+		t.Errorf("AsJSON(): Content-Length got: %q, want: %q", got, want)
+	}
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	want := `{"error":"this is an error","code":"widget_missing","status":404}` + "\n"
+	rec := httptest.NewRecorder()
+	AsJSON(rec, Because(errors.New("this is an error"), WithStatus(http.StatusNotFound), WithCode("widget_missing")))
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestWithSuggestion(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   string
+	}{
+		"with suggestion": {
+			reason: Because(errors.New("this is an error"),
+				WithStatus(http.StatusTooManyRequests),
+				WithExplanation("Chill, man!"),
+				WithSuggestion("Try again in a minute.")),
+			want: `{"error":"this is an error","explanation":"Chill, man!","suggestion":"Try again in a minute.","status":429}` + "\n",
+		},
+		"without suggestion": {
+			reason: Because(errors.New("this is an error"),
+				WithStatus(http.StatusTooManyRequests),
+				WithExplanation("Chill, man!")),
+			want: `{"error":"this is an error","explanation":"Chill, man!","status":429}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsJSON(rec, tc.reason)
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsJSON():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsJSONOmitStatus(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		render Renderer
+		want   string
+	}{
+		"status included by default": {
+			render: AsJSON,
+			want:   `{"error":"this is an error","explanation":"Chill, man!","status":420}` + "\n",
+		},
+		"status omitted": {
+			render: AsJSONOmitStatus(),
+			want:   `{"error":"this is an error","explanation":"Chill, man!"}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, Because(errors.New("this is an error"),
+				WithStatus(420),
+				WithExplanation("Chill, man!")))
+			if rec.Code != 420 {
+				t.Errorf("render: status got: %v, want: %v", rec.Code, 420)
+			}
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("render:\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsJSONIndented(t *testing.T) {
+	want := `{
+	"error": "this is an error",
+	"explanation": "Chill, man!",
+	"status": 420
+}
+`
+	rec := httptest.NewRecorder()
+	AsJSONIndented("", "\t")(rec, Because(errors.New("this is an error"),
+		WithStatus(420),
+		WithExplanation("Chill, man!")))
+	if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("AsJSONIndented(): Content-Type got: %q", got)
+	}
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsJSONIndented():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestAsJSONWith(t *testing.T) {
+	reason := Because(errors.New("expected <widget> & <sprocket>"), WithStatus(http.StatusBadRequest))
+	for tn, tc := range map[string]struct {
+		render Renderer
+		want   string
+	}{
+		"default": {
+			render: AsJSON,
+			want:   `{"error":"expected \u003cwidget\u003e \u0026 \u003csprocket\u003e","status":400}` + "\n",
+		},
+		"unescaped HTML": {
+			render: AsJSONWith(func(enc *json.Encoder) {
+				enc.SetEscapeHTML(false)
+			}),
+			want: `{"error":"expected <widget> & <sprocket>","status":400}` + "\n",
+		},
+		"indented": {
+			render: AsJSONWith(func(enc *json.Encoder) {
+				enc.SetIndent("", " ")
+			}),
+			want: "{\n \"error\": \"expected \\u003cwidget\\u003e \\u0026 \\u003csprocket\\u003e\",\n \"status\": 400\n}\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, reason)
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsJSONWith():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsJSONVersioned(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		render Renderer
+		want   string
+	}{
+		"versioned": {
+			render: AsJSONVersioned("2024-06-01"),
+			want:   `{"error":"rut-ro raggy","status":418,"api_error_version":"2024-06-01"}` + "\n",
+		},
+		"default": {
+			render: AsJSON,
+			want:   `{"error":"rut-ro raggy","status":418}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, Because(errForTesting, WithStatus(http.StatusTeapot)))
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsJSONVersioned():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsNDJSON(t *testing.T) {
+	want := `{"error":"this is an error","explanation":"Chill, man!","status":420}` + "\n"
+	rec := httptest.NewRecorder()
+	AsNDJSON(rec, Because(errors.New("this is an error"),
+		WithStatus(420),
+		WithExplanation("Chill, man!")))
+	if got := rec.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("AsNDJSON(): Content-Type got: %q", got)
+	}
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsNDJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+	if got := strings.Count(rec.Body.String(), "\n"); got != 1 {
+		t.Errorf("AsNDJSON(): got %v lines, want: 1", got)
+	}
+}
+
+func TestCharset(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		render Renderer
+		want   string
+	}{
+		"JSON default": {
+			render: AsJSON,
+			want:   "application/json; charset=utf-8",
+		},
+		"JSON charset": {
+			render: AsJSONCharset("UTF-8"),
+			want:   "application/json; charset=UTF-8",
+		},
+		"JSON no charset": {
+			render: AsJSONCharset(""),
+			want:   "application/json",
+		},
+		"text default": {
+			render: AsText,
+			want:   "text/plain; charset=utf-8",
+		},
+		"text charset": {
+			render: AsTextCharset("us-ascii"),
+			want:   "text/plain; charset=us-ascii",
+		},
+		"text no charset": {
+			render: AsTextCharset(""),
+			want:   "text/plain",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, Because(errForTesting))
+			if got := rec.Header().Get("Content-Type"); got != tc.want {
+				t.Errorf("Content-Type got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsJSONWithErrorFunc(t *testing.T) {
+	rewrite := func(e error) string {
+		var ue *url.Error
+		if errors.As(e, &ue) {
+			return "upstream request failed"
+		}
+		return e.Error()
+	}
+	for tn, tc := range map[string]struct {
+		err  error
+		want string
+	}{
+		"rewritten error type": {
+			err: fmt.Errorf("fetching widget: %w", &url.Error{
+				Op:  "Get",
+				URL: "http://secret.internal/widgets/1",
+				Err: errors.New("connection refused"),
+			}),
+			want: `{"error":"upstream request failed","status":502}` + "\n",
+		},
+		"other error type": {
+			err:  errors.New("this is an error"),
+			want: `{"error":"this is an error","status":502}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsJSONWithErrorFunc(rewrite)(rec, Because(tc.err, WithStatus(http.StatusBadGateway)))
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("AsJSONWithErrorFunc(): status got: %v, want: %v", rec.Code, http.StatusBadGateway)
+			}
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsJSONWithErrorFunc():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsText(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   string
+	}{
+		"error only": {
+			reason: Because(errors.New("this is an error"), WithStatus(420)),
+			want:   "this is an error\n",
+		},
+		"with explanation": {
+			reason: Because(errors.New("this is an error"), WithStatus(420), WithExplanation("Chill, man!")),
+			want:   "this is an error: Chill, man!\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsText(rec, tc.reason)
+			if rec.Code != 420 {
+				t.Errorf("AsText(): status got: %v, want: %v", rec.Code, 420)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+				t.Errorf("AsText(): Content-Type got: %q", got)
+			}
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsText(): body got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderersOmitBodyWhenForbidden(t *testing.T) {
+	renderers := map[string]Renderer{
+		"AsJSON": AsJSON,
+		"AsText": AsText,
+	}
+	for _, tc := range []struct {
+		status int
+		want   bool
+	}{
+		{status: http.StatusContinue},
+		{status: http.StatusEarlyHints},
+		{status: 199},
+		{status: http.StatusOK, want: true},
+		{status: http.StatusNoContent},
+		{status: http.StatusResetContent, want: true},
+		{status: http.StatusNotModified},
+		{status: http.StatusTemporaryRedirect, want: true},
+	} {
+		for name, render := range renderers {
+			t.Run(fmt.Sprintf("%v/%d", name, tc.status), func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				render(rec, Because(errForTesting, WithStatus(tc.status)))
+				if rec.Code != tc.status {
+					t.Errorf("%v: status got: %v, want: %v", name, rec.Code, tc.status)
+				}
+				if got := rec.Body.Len() > 0; got != tc.want {
+					t.Errorf("%v: body for %d got: %q, want body: %v", name, tc.status, rec.Body.String(), tc.want)
+				}
+			})
+		}
+	}
+}
+
+func TestAsEnvelopeJSON(t *testing.T) {
+	reason := Because(errors.New("this is an error"),
+		WithStatus(420),
+		WithExplanation("Chill, man!"))
+	for tn, tc := range map[string]struct {
+		render Renderer
+		want   string
+	}{
+		"default keys": {
+			render: AsEnvelopeJSON,
+			want:   `{"error":{"error":"this is an error","explanation":"Chill, man!","status":420},"success":false}` + "\n",
+		},
+		"custom keys": {
+			render: AsEnvelopeJSONWithKeys("ok", "problem"),
+			want:   `{"ok":false,"problem":{"error":"this is an error","explanation":"Chill, man!","status":420}}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, reason)
+			if rec.Code != 420 {
+				t.Errorf("AsEnvelopeJSON(): status got: %v, want: %v", rec.Code, 420)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+				t.Errorf("AsEnvelopeJSON(): Content-Type got: %q", got)
+			}
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsEnvelopeJSON():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsTrailer(t *testing.T) {
+	const trailer = "X-Final-Status"
+	for tn, tc := range map[string]struct {
+		handler     http.HandlerFunc
+		wantStatus  int
+		wantTrailer string
+	}{
+		"before response started": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", trailer)
+				panic(Because(errForTesting, WithStatus(http.StatusBadGateway)))
+			},
+			wantStatus: http.StatusBadGateway,
+		},
+		"after response started": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", trailer)
+				fmt.Fprintln(w, "first chunk")
+				w.(http.Flusher).Flush()
+				panic(Because(errForTesting, WithStatus(http.StatusBadGateway)))
+			},
+			wantStatus:  http.StatusOK,
+			wantTrailer: "502",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			GracefullyRender(tc.handler, AsTrailer(trailer)).ServeHTTP(rec, req)
+			res := rec.Result()
+			if res.StatusCode != tc.wantStatus {
+				t.Errorf("AsTrailer(): status got: %v, want: %v", res.StatusCode, tc.wantStatus)
+			}
+			if got := res.Trailer.Get(trailer); got != tc.wantTrailer {
+				t.Errorf("AsTrailer(): trailer got: %q, want: %q", got, tc.wantTrailer)
+			}
+		})
+	}
+}
+
+func TestNoBodyFor(t *testing.T) {
+	render := NoBodyFor(AsJSON, http.StatusUnauthorized, http.StatusFound)
+	for tn, tc := range map[string]struct {
+		reason   Reason
+		wantBody string
+	}{
+		"suppressed": {
+			reason: Because(errForTesting,
+				WithStatus(http.StatusUnauthorized),
+				WithHeader("WWW-Authenticate", `Bearer realm="widgets"`)),
+		},
+		"suppressed body": {
+			reason: Because(errForTesting,
+				WithStatus(http.StatusUnauthorized),
+				WithHeader("WWW-Authenticate", `Bearer realm="widgets"`),
+				WithBodyType("text/plain", []byte("go away\n"))),
+		},
+		"not suppressed": {
+			reason: Because(errForTesting,
+				WithStatus(http.StatusForbidden),
+				WithHeader("WWW-Authenticate", `Bearer realm="widgets"`)),
+			wantBody: `{"error":"rut-ro raggy","status":403}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			render(rec, tc.reason)
+			if rec.Code != tc.reason.Status {
+				t.Errorf("NoBodyFor(): status got: %v, want: %v", rec.Code, tc.reason.Status)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer realm="widgets"` {
+				t.Errorf("NoBodyFor(): WWW-Authenticate got: %q", got)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("NoBodyFor():\n got:%q\nwant:%q\n", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestNoSniff(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		render Renderer
+		reason Reason
+	}{
+		"built-in renderer": {
+			render: AsJSON,
+			reason: Because(errForTesting),
+		},
+		"verbatim body": {
+			render: NoSniff(AsJSON),
+			reason: Because(errForTesting, WithBodyType("text/html", []byte("<p>rut-ro raggy</p>"))),
+		},
+		"custom renderer": {
+			render: NoSniff(func(w http.ResponseWriter, reason Reason) {
+				w.WriteHeader(reason.Status)
+				fmt.Fprintln(w, reason)
+			}),
+			reason: Because(errForTesting),
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, tc.reason)
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options got: %q, want: %q", got, "nosniff")
+			}
+		})
+	}
+}
+
+func TestContentLengthForHTTP10(t *testing.T) {
+	unknownLength := func(w http.ResponseWriter, reason Reason) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(reason.Status)
+		fmt.Fprintln(w, reason)
+	}
+	for tn, tc := range map[string]struct {
+		major, minor      int
+		reason            Reason
+		wantContentLength string
+		wantBody          string
+	}{
+		"HTTP/1.0": {
+			major: 1, minor: 0,
+			reason:            Because(errForTesting, WithStatus(http.StatusTeapot)),
+			wantContentLength: "13",
+			wantBody:          "rut-ro raggy\n",
+		},
+		"HTTP/1.0 without body": {
+			major: 1, minor: 0,
+			reason: NoContent(),
+		},
+		"HTTP/1.1": {
+			major: 1, minor: 1,
+			reason:   Because(errForTesting, WithStatus(http.StatusTeapot)),
+			wantBody: "rut-ro raggy\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Proto = fmt.Sprintf("HTTP/%d.%d", tc.major, tc.minor)
+			req.ProtoMajor, req.ProtoMinor = tc.major, tc.minor
+			rec := httptest.NewRecorder()
+			ContentLengthForHTTP10(unknownLength)(rec, req, tc.reason)
+			if rec.Code != tc.reason.Status {
+				t.Errorf("ContentLengthForHTTP10(): status got: %v, want: %v", rec.Code, tc.reason.Status)
+			}
+			if got := rec.Header().Get("Content-Length"); got != tc.wantContentLength {
+				t.Errorf("ContentLengthForHTTP10(): Content-Length got: %q, want: %q", got, tc.wantContentLength)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+				t.Errorf("ContentLengthForHTTP10(): Content-Type got: %q, want: %q", got, "text/plain")
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("ContentLengthForHTTP10(): body got: %q, want: %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	unavailable := func(r Reason) Reason {
+		if r.Status == http.StatusInternalServerError {
+			r.Status = http.StatusServiceUnavailable
+		}
+		return r
+	}
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   int
+	}{
+		"transformed": {
+			reason: Because(errForTesting),
+			want:   http.StatusServiceUnavailable,
+		},
+		"unchanged": {
+			reason: Because(errForTesting, WithStatus(http.StatusTeapot)),
+			want:   http.StatusTeapot,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Transform(unavailable, StatusOnly)(rec, tc.reason)
+			if rec.Code != tc.want {
+				t.Errorf("Transform(): status got: %v, want: %v", rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithMaxExplanationLength(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		n           int
+		explanation string
+		want        string
+	}{
+		"unlimited": {
+			n:           0,
+			explanation: "Chill, man!",
+			want:        "Chill, man!",
+		},
+		"shorter than limit": {
+			n:           20,
+			explanation: "Chill, man!",
+			want:        "Chill, man!",
+		},
+		"exactly at limit": {
+			n:           11,
+			explanation: "Chill, man!",
+			want:        "Chill, man!",
+		},
+		"one past limit": {
+			n:           10,
+			explanation: "Chill, man!",
+			want:        "Chill, ma…",
+		},
+		"multi-byte characters": {
+			n:           4,
+			explanation: "ümlauts über alles",
+			want:        "üml…",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var got string
+			render := WithMaxExplanationLength(tc.n, func(_ http.ResponseWriter, r Reason) {
+				got = r.Explanation
+			})
+			render(httptest.NewRecorder(), Because(errForTesting, WithExplanation(tc.explanation)))
+			if got != tc.want {
+				t.Errorf("WithMaxExplanationLength(): explanation got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// equateReasons compares Reasons field by field, with the errors they wrap
+// compared using errors.Is.
+var equateReasons = cmp.Options{
+	cmp.AllowUnexported(Reason{}),
+	cmp.FilterValues(func(x, y interface{}) bool {
+		_, xr := x.(Reason)
+		_, yr := y.(Reason)
+		return !xr && !yr
+	}, cmpopts.EquateErrors()),
+}
+
+func TestReasonDescribe(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   map[string]interface{}
+	}{
+		"minimal": {
+			reason: Because(errors.New("this is an error")),
+			want: map[string]interface{}{
+				"status":     http.StatusInternalServerError,
+				"error":      "this is an error",
+				"error_type": "*errors.errorString",
+			},
+		},
+		"fully populated": {
+			reason: Because(statusCodeError{http.StatusConflict},
+				WithStatusFromError(),
+				WithCode("conflict"),
+				WithExplanation("Chill, man!"),
+				WithSuggestion("Try again later."),
+				WithBodyType("text/plain", []byte("conflict")),
+				WithInstance("/widgets/1"),
+				WithMetadata("balance", 30),
+				WithStatusFunc(func(*http.Request) int { return http.StatusConflict })),
+			want: map[string]interface{}{
+				"status":       http.StatusConflict,
+				"error":        "failed with status 409",
+				"error_type":   "httpanic.statusCodeError",
+				"code":         "conflict",
+				"explanation":  "Chill, man!",
+				"suggestion":   "Try again later.",
+				"body_length":  8,
+				"content_type": "text/plain",
+				"instance":     "/widgets/1",
+				"metadata":     map[string]interface{}{"balance": 30},
+				"status_func":  true,
+			},
+		},
+		"with cause": {
+			reason: Because(errors.New("this is an error"),
+				WithCause(statusCodeError{http.StatusConflict}),
+				WithUnwrapCause(true)),
+			want: map[string]interface{}{
+				"status":       http.StatusInternalServerError,
+				"error":        "this is an error",
+				"error_type":   "*errors.errorString",
+				"cause":        "failed with status 409",
+				"cause_type":   "httpanic.statusCodeError",
+				"unwrap_cause": true,
+			},
+		},
+		"recovered": {
+			reason: Reason{error: errors.New("this is an error"), recovered: "this is an error"},
+			want: map[string]interface{}{
+				"status":         0,
+				"error":          "this is an error",
+				"error_type":     "*errors.errorString",
+				"recovered_type": "string",
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got := tc.reason.Describe()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Reason.Describe(): mismatch (-want +got):\n%v", diff)
+			}
+			if _, err := json.Marshal(got); err != nil {
+				t.Errorf("Reason.Describe(): description does not marshal: %v", err)
+			}
+		})
+	}
+}
+
 func TestBecause(t *testing.T) {
 	testErr := errors.New("test error, please ignore")
 	for tn, tc := range map[string]struct {
@@ -74,6 +937,29 @@ func TestBecause(t *testing.T) {
 				Explanation: "Chill, man!",
 			},
 		},
+		"with body": {
+			err: testErr,
+			additional: []Detail{
+				WithBodyType("text/html", []byte("<h1>Oops</h1>")),
+			},
+			want: Reason{
+				error:       testErr,
+				Status:      http.StatusInternalServerError,
+				Body:        []byte("<h1>Oops</h1>"),
+				ContentType: "text/html",
+			},
+		},
+		"with sniffed body": {
+			err: testErr,
+			additional: []Detail{
+				WithBody([]byte("<h1>Oops</h1>")),
+			},
+			want: Reason{
+				error:  testErr,
+				Status: http.StatusInternalServerError,
+				Body:   []byte("<h1>Oops</h1>"),
+			},
+		},
 		"latest additional reason wins": {
 			err: testErr,
 			additional: []Detail{
@@ -91,9 +977,417 @@ func TestBecause(t *testing.T) {
 		},
 	} {
 		t.Run(tn, func(t *testing.T) {
-			got := Because(tc.err, tc.additional...)
-			if diff := cmp.Diff(tc.want, got, cmpopts.EquateErrors()); diff != "" {
-				t.Errorf("Because(): return value mismatch (-want +got):\n%v", diff)
+			got := Because(tc.err, tc.additional...)
+			if diff := cmp.Diff(tc.want, got, equateReasons); diff != "" {
+				t.Errorf("Because(): return value mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWithBodyContentType(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		deet Detail
+		want string
+	}{
+		"sniffed html": {
+			deet: WithBody([]byte("<!DOCTYPE html><h1>Oops</h1>")),
+			want: "text/html; charset=utf-8",
+		},
+		"sniffed text": {
+			deet: WithBody([]byte("oops")),
+			want: "text/plain; charset=utf-8",
+		},
+		"explicit": {
+			deet: WithBodyType("application/vnd.widgets+json", []byte(`{"oops":true}`)),
+			want: "application/vnd.widgets+json",
+		},
+		"explicit empty": {
+			deet: WithBodyType("", []byte("<html><p>Oops</p></html>")),
+			want: "text/html; charset=utf-8",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsJSON(rec, Because(errForTesting, tc.deet))
+			if got := rec.Header().Get("Content-Type"); got != tc.want {
+				t.Errorf("WithBody(): Content-Type got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithUnwrapCause(t *testing.T) {
+	errPublic := errors.New("could not save widget")
+	errCause := fmt.Errorf("inserting row: %w", errors.New("connection reset"))
+	errSentinel := errors.Unwrap(errCause)
+	for tn, tc := range map[string]struct {
+		additional []Detail
+		wantPublic bool
+		wantCause  bool
+	}{
+		"default unwraps primary error": {
+			additional: []Detail{WithCause(errCause)},
+			wantPublic: true,
+		},
+		"explicitly unwrap primary error": {
+			additional: []Detail{WithCause(errCause), WithUnwrapCause(false)},
+			wantPublic: true,
+		},
+		"unwrap cause": {
+			additional: []Detail{WithCause(errCause), WithUnwrapCause(true)},
+			wantCause:  true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			reason := Because(errPublic, tc.additional...)
+			if got := errors.Is(reason, errPublic); got != tc.wantPublic {
+				t.Errorf("errors.Is(reason, primary): got: %v, want: %v", got, tc.wantPublic)
+			}
+			if got := errors.Is(reason, errSentinel); got != tc.wantCause {
+				t.Errorf("errors.Is(reason, cause): got: %v, want: %v", got, tc.wantCause)
+			}
+			if got, want := reason.Error(), "could not save widget"; got != want {
+				t.Errorf("Reason.Error(): got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestBecausef(t *testing.T) {
+	errSentinel := errors.New("widget not found")
+	got := Becausef(http.StatusNotFound, "loading widget %d: %w", 42, errSentinel)
+	if got.Status != http.StatusNotFound {
+		t.Errorf("Becausef(): status got: %v, want: %v", got.Status, http.StatusNotFound)
+	}
+	if want := "loading widget 42: widget not found"; got.Error() != want {
+		t.Errorf("Becausef(): error got: %q, want: %q", got.Error(), want)
+	}
+	if !errors.Is(got, errSentinel) {
+		t.Errorf("Becausef(): errors.Is(%v, %v): got: false, want: true", got, errSentinel)
+	}
+	if errors.Is(Becausef(http.StatusNotFound, "loading widget %d: %v", 42, errSentinel), errSentinel) {
+		t.Error("Becausef(): errors.Is() for unwrapped error: got: true, want: false")
+	}
+}
+
+func TestNoContent(t *testing.T) {
+	reason := NoContent(WithExplanation("Nothing to see here."))
+	if reason.Status != http.StatusNoContent {
+		t.Errorf("NoContent(): status got: %v, want: %v", reason.Status, http.StatusNoContent)
+	}
+	for tn, render := range map[string]Renderer{
+		"default":       StatusOnly,
+		"AsJSON":        AsJSON,
+		"AsEnvelope":    AsEnvelopeJSON,
+		"AsProblemJSON": AsProblemJSON,
+		"AsProblemXML":  AsProblemXML,
+		"WithBody": func(w http.ResponseWriter, r Reason) {
+			AsJSON(w, Because(r, WithStatus(r.Status), WithBodyType("text/plain", []byte("oops"))))
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			render(rec, reason)
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("%v: status got: %v, want: %v", tn, rec.Code, http.StatusNoContent)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("%v: body got: %q, want none", tn, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason     Reason
+		wantStatus int
+		wantError  string
+	}{
+		"not found": {
+			reason:     Status(http.StatusNotFound),
+			wantStatus: http.StatusNotFound,
+			wantError:  "Not Found",
+		},
+		"with details": {
+			reason:     Status(http.StatusTooManyRequests, WithHeader("Retry-After", "120")),
+			wantStatus: http.StatusTooManyRequests,
+			wantError:  "Too Many Requests",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if tc.reason.Status != tc.wantStatus {
+				t.Errorf("Status(): status got: %v, want: %v", tc.reason.Status, tc.wantStatus)
+			}
+			if got := tc.reason.Error(); got != tc.wantError {
+				t.Errorf("Status(): error got: %q, want: %q", got, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestNotImplemented(t *testing.T) {
+	reason := NotImplemented()
+	if reason.Status != http.StatusNotImplemented {
+		t.Errorf("NotImplemented(): status got: %v, want: %v", reason.Status, http.StatusNotImplemented)
+	}
+	if reason.Explanation != "not implemented" {
+		t.Errorf("NotImplemented(): explanation got: %q, want: %q", reason.Explanation, "not implemented")
+	}
+
+	rec := httptest.NewRecorder()
+	Gracefully(Stub()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("Stub(): status got: %v, want: %v", rec.Code, http.StatusNotImplemented)
+	}
+	if got, want := rec.Body.String(), "Not Implemented\n"; got != want {
+		t.Errorf("Stub(): body got: %q, want: %q", got, want)
+	}
+}
+
+// statusCodeError is an error which knows its own HTTP status.
+type statusCodeError struct {
+	status int
+}
+
+func (e statusCodeError) Error() string {
+	return fmt.Sprintf("failed with status %d", e.status)
+}
+
+func (e statusCodeError) StatusCode() int {
+	return e.status
+}
+
+func TestWithStatusFromError(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		err        error
+		additional []Detail
+		want       int
+	}{
+		"status coder": {
+			err:        statusCodeError{http.StatusConflict},
+			additional: []Detail{WithStatusFromError()},
+			want:       http.StatusConflict,
+		},
+		"wrapped status coder": {
+			err:        fmt.Errorf("saving widget: %w", statusCodeError{http.StatusConflict}),
+			additional: []Detail{WithStatusFromError()},
+			want:       http.StatusConflict,
+		},
+		"not a status coder": {
+			err:        errors.New("this is an error"),
+			additional: []Detail{WithStatusFromError()},
+			want:       http.StatusInternalServerError,
+		},
+		"not a status coder keeps earlier status": {
+			err:        errors.New("this is an error"),
+			additional: []Detail{WithStatus(420), WithStatusFromError()},
+			want:       420,
+		},
+		"later status wins": {
+			err:        statusCodeError{http.StatusConflict},
+			additional: []Detail{WithStatusFromError(), WithStatus(420)},
+			want:       420,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := Because(tc.err, tc.additional...).Status; got != tc.want {
+				t.Errorf("Because(): status got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithExplanationFor(t *testing.T) {
+	was := Environment
+	t.Cleanup(func() { Environment = was })
+	deets := []Detail{
+		WithStatus(http.StatusBadGateway),
+		WithExplanation("Try again later."),
+		WithExplanationFor("development", "The widgets database at db-7:5432 refused the connection."),
+		WithExplanationFor("production", "The widget service is unavailable."),
+	}
+	for tn, tc := range map[string]struct {
+		env  string
+		want string
+	}{
+		"development": {
+			env:  "development",
+			want: `{"error":"rut-ro raggy","explanation":"The widgets database at db-7:5432 refused the connection.","status":502}` + "\n",
+		},
+		"production": {
+			env:  "production",
+			want: `{"error":"rut-ro raggy","explanation":"The widget service is unavailable.","status":502}` + "\n",
+		},
+		"unlisted": {
+			env:  "staging",
+			want: `{"error":"rut-ro raggy","explanation":"Try again later.","status":502}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			Environment = tc.env
+			rec := httptest.NewRecorder()
+			AsJSON(rec, Because(errForTesting, deets...))
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("WithExplanationFor():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithDeprecation(t *testing.T) {
+	sunset := time.Date(2025, time.December, 31, 18, 59, 59, 0, time.FixedZone("EST", -5*60*60))
+	reason := Because(errForTesting, WithStatus(http.StatusGone), WithDeprecation(sunset))
+	rec := httptest.NewRecorder()
+	AsJSON(rec, reason)
+	if got, want := rec.Header().Get("Deprecation"), "true"; got != want {
+		t.Errorf("WithDeprecation(): Deprecation got: %q, want: %q", got, want)
+	}
+	if got, want := rec.Header().Get("Sunset"), "Wed, 31 Dec 2025 23:59:59 GMT"; got != want {
+		t.Errorf("WithDeprecation(): Sunset got: %q, want: %q", got, want)
+	}
+	if got, err := http.ParseTime(rec.Header().Get("Sunset")); err != nil || !got.Equal(sunset) {
+		t.Errorf("WithDeprecation(): Sunset parsed got: %v, %v, want: %v", got, err, sunset)
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	reason := Because(errForTesting,
+		WithStatus(http.StatusTooManyRequests),
+		WithHeader("Retry-After", "120"),
+		WithHeader("X-Reason", "one"),
+		WithHeader("X-Reason", "two"))
+	want := http.Header{
+		"Retry-After": {"120"},
+		"X-Reason":    {"one", "two"},
+	}
+	if diff := cmp.Diff(want, reason.Headers); diff != "" {
+		t.Errorf("WithHeader(): headers mismatch (-want +got):\n%v", diff)
+	}
+	for tn, render := range map[string]Renderer{
+		"default":       StatusOnly,
+		"AsJSON":        AsJSON,
+		"AsText":        AsText,
+		"AsEnvelope":    AsEnvelopeJSON,
+		"AsProblemJSON": AsProblemJSON,
+		"AsProblemXML":  AsProblemXML,
+		"WithBody": func(w http.ResponseWriter, r Reason) {
+			r.Body = []byte("oops")
+			AsJSON(w, r)
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			render(rec, reason)
+			for k, vs := range want {
+				if diff := cmp.Diff(vs, rec.Header().Values(k)); diff != "" {
+					t.Errorf("%v: %v header mismatch (-want +got):\n%v", tn, k, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestReasonClone(t *testing.T) {
+	original := Because(errForTesting,
+		WithHeader("X-Base", "yes"),
+		WithFieldErrors(FieldError{Field: "name", Message: "is required"}),
+		WithMetadata("balance", 30),
+		WithBodyType("text/plain", []byte("base")),
+		WithSubProblem(Because(errForTesting, WithHeader("X-Sub", "yes"))))
+	want := Because(errForTesting,
+		WithHeader("X-Base", "yes"),
+		WithFieldErrors(FieldError{Field: "name", Message: "is required"}),
+		WithMetadata("balance", 30),
+		WithBodyType("text/plain", []byte("base")),
+		WithSubProblem(Because(errForTesting, WithHeader("X-Sub", "yes"))))
+
+	clone := original.Clone()
+	if diff := cmp.Diff(original, clone, equateReasons); diff != "" {
+		t.Errorf("Reason.Clone(): mismatch (-original +clone):\n%v", diff)
+	}
+	clone.Headers.Set("X-Base", "no")
+	clone.Headers.Add("X-Request-Id", "clone")
+	clone.FieldErrors[0].Message = "is too long"
+	clone.Body[0] = 'B'
+	clone.Metadata["balance"] = 0
+	clone.Metadata["accounts"] = []string{"/account/12345"}
+	clone.SubProblems[0].Headers.Set("X-Sub", "no")
+	clone.SubProblems = append(clone.SubProblems, Because(errForTesting))
+	if diff := cmp.Diff(want, original, equateReasons); diff != "" {
+		t.Errorf("Reason.Clone(): original modified through clone (-want +got):\n%v", diff)
+	}
+}
+
+func TestReasonCloneEmptyBody(t *testing.T) {
+	clone := Because(errForTesting, WithBodyType("text/plain", []byte{})).Clone()
+	if clone.Body == nil {
+		t.Errorf("Reason.Clone(): empty Body became nil")
+	}
+}
+
+func TestReasonWithHeader(t *testing.T) {
+	base := Because(errForTesting, WithHeader("X-Base", "yes"))
+	first := base.WithHeader("X-Request-Id", "first")
+	second := base.WithHeader("X-Request-Id", "second")
+	fromFirst := first.WithHeader("X-Extra", "more")
+
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   http.Header
+	}{
+		"base": {
+			reason: base,
+			want:   http.Header{"X-Base": {"yes"}},
+		},
+		"first": {
+			reason: first,
+			want:   http.Header{"X-Base": {"yes"}, "X-Request-Id": {"first"}},
+		},
+		"second": {
+			reason: second,
+			want:   http.Header{"X-Base": {"yes"}, "X-Request-Id": {"second"}},
+		},
+		"derived from first": {
+			reason: fromFirst,
+			want:   http.Header{"X-Base": {"yes"}, "X-Request-Id": {"first"}, "X-Extra": {"more"}},
+		},
+		"from zero Reason": {
+			reason: Reason{}.WithHeader("X-Request-Id", "zero"),
+			want:   http.Header{"X-Request-Id": {"zero"}},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.reason.Headers); diff != "" {
+				t.Errorf("Reason.WithHeader(): headers mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWithBodyRendersVerbatim(t *testing.T) {
+	body := []byte("<html><body><h1>Cached error page</h1></body></html>")
+	for tn, render := range map[string]Renderer{
+		"default":       StatusOnly,
+		"AsJSON":        AsJSON,
+		"AsEnvelope":    AsEnvelopeJSON,
+		"AsProblemJSON": AsProblemJSON,
+		"AsProblemXML":  AsProblemXML,
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			render(rec, Because(errForTesting,
+				WithStatus(http.StatusServiceUnavailable),
+				WithBodyType("text/html; charset=utf-8", body)))
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Errorf("%v: status got: %v, want: %v", tn, rec.Code, http.StatusServiceUnavailable)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Errorf("%v: Content-Type got: %q", tn, got)
+			}
+			if got := rec.Body.Bytes(); !bytes.Equal(got, body) {
+				t.Errorf("%v: body got: %q, want: %q", tn, got, body)
 			}
 		})
 	}
@@ -101,11 +1395,73 @@ func TestBecause(t *testing.T) {
 
 var errForTesting = errors.New("rut-ro raggy")
 
-// cuzTest is a reasoner which creates does nothing fancy.
+// cuzTest is a Reasoner which does nothing fancy.
 func cuzTest(e error, _ ...Detail) Reason {
 	return Reason{error: e}
 }
 
+// weirdPanic is a value which this package does not know how to recover from.
+type weirdPanic struct {
+	reason string
+}
+
+func TestAttemptToRecoverRepanicsOriginal(t *testing.T) {
+	original := &weirdPanic{"this would be weird"}
+	var recovered interface{}
+	var stack string
+	func() {
+		defer func() {
+			recovered = recover()
+			stack = string(debug.Stack())
+		}()
+		GracefullyRender(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panicWeirdly(original)
+		}), StatusOnly).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	got, ok := recovered.(*weirdPanic)
+	if !ok {
+		t.Fatalf("re-panicked value: got: %T, want: %T", recovered, original)
+	}
+	if got != original {
+		t.Errorf("re-panicked value: got: %p, want the original %p", got, original)
+	}
+	if !strings.Contains(stack, "panicWeirdly") {
+		t.Errorf("re-panicked stack does not lead to the original panic:\n%v", stack)
+	}
+}
+
+//go:noinline
+func panicWeirdly(v interface{}) {
+	panic(v)
+}
+
+func TestReasonRecovered(t *testing.T) {
+	reason := Because(errForTesting, WithStatus(http.StatusTeapot))
+	for tn, tc := range map[string]struct {
+		p interface{}
+	}{
+		"string": {p: "this is a string"},
+		"error":  {p: errForTesting},
+		"reason": {p: reason},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var got interface{}
+			handler := GracefullyRender(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tc.p)
+			}), func(_ http.ResponseWriter, r Reason) {
+				got = r.Recovered()
+			})
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			if diff := cmp.Diff(tc.p, got, equateReasons); diff != "" {
+				t.Errorf("Reason.Recovered(): mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+	if got := reason.Recovered(); got != nil {
+		t.Errorf("Reason.Recovered(): got: %v, want: nil for a Reason which was not recovered", got)
+	}
+}
+
 func TestAttemptToRecover(t *testing.T) {
 	cmpOpts := []cmp.Option{
 		cmp.Comparer(func(x, y error) bool {
@@ -148,9 +1504,735 @@ func TestAttemptToRecover(t *testing.T) {
 						t.Errorf("attemptToRecover(): render argument mismatch (-want, +got):\n%v", diff)
 					}
 				}
-				defer attemptToRecover(&httptest.ResponseRecorder{}, tcRender, cuzTest)
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				defer attemptToRecover(&httptest.ResponseRecorder{}, req, IgnoreRequest(tcRender), withoutRequest(cuzTest))
 				panic(tc.p)
 			}(t)
 		})
 	}
 }
+
+func TestGracefullyRenderErrorLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+	handler := GracefullyRenderErrorLog(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+	}), StatusOnly, logger)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:4321"
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("GracefullyRenderErrorLog(): status got: %v, want: %v", rec.Code, http.StatusTeapot)
+	}
+	got := buf.String()
+	if want := "http: panic serving 192.0.2.1:4321: rut-ro raggy\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("GracefullyRenderErrorLog(): log entry got: %q, want prefix: %q", got, want)
+	}
+	if want := "goroutine "; !strings.Contains(got, want) {
+		t.Errorf("GracefullyRenderErrorLog(): log entry missing stack: %q", got)
+	}
+}
+
+func TestMarkLogged(t *testing.T) {
+	reason := Because(errForTesting)
+	if reason.WasLogged() {
+		t.Errorf("WasLogged(): got: true for a new Reason, want: false")
+	}
+	marked := reason.MarkLogged()
+	if !marked.WasLogged() {
+		t.Errorf("WasLogged(): got: false for a marked Reason, want: true")
+	}
+	if reason.WasLogged() {
+		t.Errorf("MarkLogged(): original Reason was modified")
+	}
+}
+
+func TestGracefullyRenderErrorLogSkipsLogged(t *testing.T) {
+	var buf bytes.Buffer
+	var rendered Reason
+	handler := GracefullyRenderErrorLog(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)).MarkLogged())
+	}), func(w http.ResponseWriter, reason Reason) {
+		rendered = reason
+		StatusOnly(w, reason)
+	}, log.New(&buf, "", 0))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("GracefullyRenderErrorLog(): status got: %v, want: %v", rec.Code, http.StatusTeapot)
+	}
+	if got := buf.String(); got != "" {
+		t.Errorf("GracefullyRenderErrorLog(): logged a marked Reason: %q", got)
+	}
+	if !rendered.WasLogged() {
+		t.Errorf("GracefullyRenderErrorLog(): rendered Reason not marked as logged")
+	}
+}
+
+func TestGracefullyRenderRequest(t *testing.T) {
+	var gotPath string
+	render := func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		gotPath = r.URL.Path
+		w.WriteHeader(reason.Status)
+	}
+	handler := GracefullyRenderRequest(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+	}), render)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/widgets/1", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("GracefullyRenderRequest(): status got: %v, want: %v", rec.Code, http.StatusTeapot)
+	}
+	if want := "/widgets/1"; gotPath != want {
+		t.Errorf("GracefullyRenderRequest(): render got request for: %q, want: %q", gotPath, want)
+	}
+}
+
+func TestWithStatusFunc(t *testing.T) {
+	errUnsupported := errors.New("unsupported operation")
+	byMethod := func(r *http.Request) int {
+		if r.Method == http.MethodDelete {
+			return http.StatusMethodNotAllowed
+		}
+		return http.StatusBadRequest
+	}
+	handler := Gracefully(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errUnsupported, WithStatusFunc(byMethod)))
+	}))
+	for _, tc := range []struct {
+		method string
+		want   int
+	}{
+		{method: http.MethodDelete, want: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, want: http.StatusBadRequest},
+	} {
+		t.Run(tc.method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, "/", nil))
+			if rec.Code != tc.want {
+				t.Errorf("WithStatusFunc(): status for %v got: %v, want: %v", tc.method, rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestGracefullyInsideTimeoutHandler(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		timeout    time.Duration
+		handler    func(http.ResponseWriter)
+		wantStatus int
+	}{
+		"panic after timeout": {
+			timeout: 10 * time.Millisecond,
+			handler: func(w http.ResponseWriter) {
+				time.Sleep(50 * time.Millisecond)
+				w.WriteHeader(http.StatusAccepted)
+				panic(Because(errForTesting))
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		"panic after status written": {
+			timeout: time.Minute,
+			handler: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusAccepted)
+				panic(Because(errForTesting))
+			},
+			wantStatus: http.StatusAccepted,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rendered := make(chan struct{})
+			render := func(w http.ResponseWriter, reason Reason) {
+				defer close(rendered)
+				StatusOnly(w, reason)
+			}
+			handler := http.TimeoutHandler(GracefullyRender(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				tc.handler(w)
+			}), render), tc.timeout, "timed out")
+
+			var errorLog bytes.Buffer
+			srv := httptest.NewUnstartedServer(handler)
+			srv.Config.ErrorLog = log.New(&errorLog, "", 0)
+			srv.Start()
+			defer srv.Close()
+
+			res, err := http.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("GET: unexpected error: %v", err)
+			}
+			res.Body.Close()
+			<-rendered
+
+			if res.StatusCode != tc.wantStatus {
+				t.Errorf("status got: %v, want: %v", res.StatusCode, tc.wantStatus)
+			}
+			if got := errorLog.String(); got != "" {
+				t.Errorf("unexpected server error log: %v", got)
+			}
+		})
+	}
+}
+
+func TestGracefullyAfterEarlyHints(t *testing.T) {
+	srv := httptest.NewServer(Gracefully(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		panic(Because(errForTesting, WithStatus(http.StatusBadGateway)))
+	})))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway {
+		t.Errorf("status got: %v, want: %v", res.StatusCode, http.StatusBadGateway)
+	}
+}
+
+func TestGracefullyReason(t *testing.T) {
+	errMissing := errors.New("widget is missing")
+	cuz := func(r *http.Request, e error, deets ...Detail) Reason {
+		if r.Method == http.MethodGet {
+			deets = append([]Detail{WithStatus(http.StatusNotFound)}, deets...)
+		}
+		return Because(e, deets...)
+	}
+	handler := GracefullyReason(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(errMissing)
+	}), IgnoreRequest(StatusOnly), cuz)
+	for _, tc := range []struct {
+		method string
+		want   int
+	}{
+		{method: http.MethodGet, want: http.StatusNotFound},
+		{method: http.MethodPut, want: http.StatusInternalServerError},
+	} {
+		t.Run(tc.method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, "/", nil))
+			if rec.Code != tc.want {
+				t.Errorf("GracefullyReason(): status for %v got: %v, want: %v", tc.method, rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestGracefullyFunc(t *testing.T) {
+	panicky := func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+	}
+	for tn, tc := range map[string]struct {
+		handler  http.HandlerFunc
+		wantBody string
+	}{
+		"with renderer": {
+			handler:  GracefullyFunc(panicky, AsText),
+			wantBody: "rut-ro raggy\n",
+		},
+		"default renderer": {
+			handler:  GracefullyFuncDefault(panicky),
+			wantBody: "I'm a teapot\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusTeapot {
+				t.Errorf("status got: %v, want: %v", rec.Code, http.StatusTeapot)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("body got: %q, want: %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestGracefullyRenderOnSuccess(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		handler http.HandlerFunc
+		want    bool
+	}{
+		"success": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprintln(w, "Looks good!")
+			},
+			want: true,
+		},
+		"panic": {
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic(Because(errForTesting))
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var called bool
+			handler := GracefullyRenderOnSuccess(tc.handler, StatusOnly, func(*http.Request) {
+				called = true
+			})
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			if called != tc.want {
+				t.Errorf("GracefullyRenderOnSuccess(): onSuccess called: %v, want: %v", called, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		handler    func(http.ResponseWriter, *http.Request) error
+		wantStatus int
+		wantBody   string
+	}{
+		"no error": {
+			handler: func(w http.ResponseWriter, _ *http.Request) error {
+				fmt.Fprintln(w, "Looks good!")
+				return nil
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Looks good!\n",
+		},
+		"plain error": {
+			handler: func(http.ResponseWriter, *http.Request) error {
+				return errForTesting
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "rut-ro raggy\n",
+		},
+		"reason": {
+			handler: func(http.ResponseWriter, *http.Request) error {
+				return Because(errForTesting, WithStatus(http.StatusTeapot), WithExplanation("Chill, man!"))
+			},
+			wantStatus: http.StatusTeapot,
+			wantBody:   "rut-ro raggy: Chill, man!\n",
+		},
+		"wrapped reason": {
+			handler: func(http.ResponseWriter, *http.Request) error {
+				return fmt.Errorf("loading widget: %w", Because(errForTesting, WithStatus(http.StatusTeapot), WithExplanation("Chill, man!")))
+			},
+			wantStatus: http.StatusTeapot,
+			wantBody:   "rut-ro raggy: Chill, man!\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			HandleError(tc.handler, Because, AsText).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("HandleError(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("HandleError(): body got: %q, want: %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleStatusError(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		handler    func(http.ResponseWriter, *http.Request) (int, error)
+		wantStatus int
+		wantBody   string
+	}{
+		"no error": {
+			handler: func(w http.ResponseWriter, _ *http.Request) (int, error) {
+				fmt.Fprintln(w, "Looks good!")
+				return http.StatusOK, nil
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Looks good!\n",
+		},
+		"plain error": {
+			handler: func(http.ResponseWriter, *http.Request) (int, error) {
+				return http.StatusBadGateway, errForTesting
+			},
+			wantStatus: http.StatusBadGateway,
+			wantBody:   "rut-ro raggy\n",
+		},
+		"no status": {
+			handler: func(http.ResponseWriter, *http.Request) (int, error) {
+				return 0, errForTesting
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "rut-ro raggy\n",
+		},
+		"reason": {
+			handler: func(http.ResponseWriter, *http.Request) (int, error) {
+				return http.StatusTeapot, Because(errForTesting, WithExplanation("Chill, man!"))
+			},
+			wantStatus: http.StatusTeapot,
+			wantBody:   "rut-ro raggy: Chill, man!\n",
+		},
+		"wrapped reason": {
+			handler: func(http.ResponseWriter, *http.Request) (int, error) {
+				return http.StatusTeapot, fmt.Errorf("loading widget: %w", Because(errForTesting, WithExplanation("Chill, man!")))
+			},
+			wantStatus: http.StatusTeapot,
+			wantBody:   "rut-ro raggy: Chill, man!\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			HandleStatusError(tc.handler, AsText).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("HandleStatusError(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("HandleStatusError(): body got: %q, want: %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestGracefullyIf(t *testing.T) {
+	handler := GracefullyIf(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+	}), StatusOnly, func(r *http.Request) bool {
+		return r.URL.Path != "/passthrough"
+	})
+	for tn, tc := range map[string]struct {
+		path        string
+		wantStatus  int
+		wantRecover bool
+	}{
+		"recovering": {
+			path:       "/recovering",
+			wantStatus: http.StatusTeapot,
+		},
+		"pass through": {
+			path:        "/passthrough",
+			wantStatus:  http.StatusOK,
+			wantRecover: true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			var recovered interface{}
+			func() {
+				defer func() {
+					recovered = recover()
+				}()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			}()
+			if got := recovered != nil; got != tc.wantRecover {
+				t.Errorf("GracefullyIf(): panic propagated: %v, want: %v", got, tc.wantRecover)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Errorf("GracefullyIf(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGracefullyNested(t *testing.T) {
+	var outer, inner int
+	counting := func(n *int) Renderer {
+		return func(w http.ResponseWriter, reason Reason) {
+			*n++
+			StatusOnly(w, reason)
+		}
+	}
+	handler := GracefullyRender(GracefullyRender(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+	}), counting(&inner)), counting(&outer))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("nested Gracefully: status got: %v, want: %v", rec.Code, http.StatusTeapot)
+	}
+	if outer != 0 || inner != 1 {
+		t.Errorf("nested Gracefully: outer rendered %d times, inner %d times, want 0 and 1", outer, inner)
+	}
+}
+
+func TestReasonFrom(t *testing.T) {
+	var runtimeErr error
+	func() {
+		defer func() {
+			runtimeErr = recover().(error)
+		}()
+		var widgets map[string]int
+		widgets["sprocket"]++
+	}()
+	for tn, tc := range map[string]struct {
+		recovered         interface{}
+		want              Reason
+		wantOK            bool
+		wantClientMessage string
+	}{
+		"reason": {
+			recovered: Because(errForTesting, WithStatus(http.StatusTeapot)),
+			want:      Because(errForTesting, WithStatus(http.StatusTeapot)),
+			wantOK:    true,
+		},
+		"error": {
+			recovered: errForTesting,
+			want:      Because(errForTesting, WithStatus(http.StatusBadGateway)),
+			wantOK:    true,
+		},
+		"string": {
+			recovered: "this is a string",
+			want:      Because(errors.New("this is a string"), WithStatus(http.StatusBadGateway)),
+			wantOK:    true,
+		},
+		"runtime error": {
+			recovered:         runtimeErr,
+			want:              Because(runtimeErr, WithStatus(http.StatusBadGateway)),
+			wantOK:            true,
+			wantClientMessage: "Internal Server Error",
+		},
+		"unclassifiable": {
+			recovered: &weirdPanic{"this would be weird"},
+		},
+		"nil": {},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := ReasonFrom(tc.recovered, func(e error, deets ...Detail) Reason {
+				return Because(e, append([]Detail{WithStatus(http.StatusBadGateway)}, deets...)...)
+			})
+			if ok != tc.wantOK {
+				t.Fatalf("ReasonFrom(): ok got: %v, want: %v", ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff(tc.recovered, got.Recovered(), equateReasons); diff != "" {
+				t.Errorf("ReasonFrom(): Recovered() mismatch (-want +got):\n%v", diff)
+			}
+			if got.clientMessage != tc.wantClientMessage {
+				t.Errorf("ReasonFrom(): client message got: %q, want: %q", got.clientMessage, tc.wantClientMessage)
+			}
+			got.recovered, got.clientMessage = nil, ""
+			if diff := cmp.Diff(tc.want, got, equateDecodedReasons); diff != "" {
+				t.Errorf("ReasonFrom(): mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRenderOnce(t *testing.T) {
+	var renders int
+	counting := func(w http.ResponseWriter, _ *http.Request, reason Reason) {
+		renders++
+		StatusOnly(w, reason)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), recoveringKey{}, &renderState{}))
+	func() {
+		defer attemptToRecover(rec, req, counting, withoutRequest(Because))
+		func() {
+			defer attemptToRecover(rec, req, counting, withoutRequest(Because))
+			panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+		}()
+		panic(Because(errForTesting, WithStatus(http.StatusBadGateway)))
+	}()
+	if renders != 1 {
+		t.Errorf("attemptToRecover(): rendered %d times, want 1", renders)
+	}
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("attemptToRecover(): status got: %v, want: %v", rec.Code, http.StatusTeapot)
+	}
+}
+
+func TestRenderOnceHandleError(t *testing.T) {
+	var renders int
+	handler := GracefullyRender(HandleError(func(http.ResponseWriter, *http.Request) error {
+		return Because(errForTesting, WithStatus(http.StatusTeapot))
+	}, Because, func(w http.ResponseWriter, reason Reason) {
+		renders++
+		StatusOnly(w, reason)
+		panic(errForTesting)
+	}), StatusOnly)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if renders != 1 {
+		t.Errorf("HandleError(): rendered %d times, want 1", renders)
+	}
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("HandleError(): status got: %v, want: %v", rec.Code, http.StatusTeapot)
+	}
+}
+
+func TestConcurrentRender(t *testing.T) {
+	reason := Because(errForTesting,
+		WithStatus(http.StatusTooManyRequests),
+		WithExplanation("Chill, man!"),
+		WithHeader("Retry-After", "120"),
+		WithFieldErrors(FieldError{Field: "name", Message: "is required"}))
+	verbatim := Because(errForTesting, WithHeader("Retry-After", "120"), WithBodyType("text/plain", []byte("verbatim")))
+	for tn, render := range map[string]Renderer{
+		"StatusOnly":         StatusOnly,
+		"TextStatusRenderer": TextStatusRenderer,
+		"AsJSON":             AsJSON,
+		"AsJSONFast":         AsJSONFast,
+		"AsText":             AsText,
+		"AsEnvelopeJSON":     AsEnvelopeJSON,
+		"AsProblemJSON":      AsProblemJSON,
+		"AsProblemXML":       AsProblemXML,
+		"NoSniff":            NoSniff(AsJSON),
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for _, r := range []Reason{reason, verbatim} {
+						rec := httptest.NewRecorder()
+						render(rec, r)
+						// Modifying the response must not modify the Reason.
+						rec.Header().Add("Retry-After", "now")
+					}
+				}()
+			}
+			wg.Wait()
+			if got := reason.Headers.Values("Retry-After"); len(got) != 1 {
+				t.Errorf("%v: Reason headers modified by render: %v", tn, got)
+			}
+		})
+	}
+}
+
+func TestRuntimeErrorWithheld(t *testing.T) {
+	var logged bytes.Buffer
+	var recovered interface{}
+	handler := GracefullyRenderErrorLog(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		var widgets map[string]int
+		widgets["sprocket"]++
+	}), func(w http.ResponseWriter, reason Reason) {
+		recovered = reason.Recovered()
+		AsJSON(w, reason)
+	}, log.New(&logged, "", 0))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status got: %v, want: %v", rec.Code, http.StatusInternalServerError)
+	}
+	want := `{"error":"Internal Server Error","status":500}` + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body:\n got:%v\nwant:%v\n", got, want)
+	}
+	if got := logged.String(); !strings.Contains(got, "assignment to entry in nil map") {
+		t.Errorf("log got: %q, want the runtime error message", got)
+	}
+	if _, ok := recovered.(runtime.Error); !ok {
+		t.Errorf("Reason.Recovered(): got: %T, want a runtime.Error", recovered)
+	}
+}
+
+func TestGracefullyRenderAfter(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		render      Renderer
+		wantEvents  []string
+		wantRecover bool
+	}{
+		"rendered": {
+			render: func(w http.ResponseWriter, reason Reason) {
+				StatusOnly(w, reason)
+			},
+			wantEvents: []string{"render", "after 418"},
+		},
+		"renderer panicked": {
+			render: func(http.ResponseWriter, Reason) {
+				panic("renderer failed")
+			},
+			wantEvents:  []string{"render", "after 418"},
+			wantRecover: true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var events []string
+			handler := GracefullyRenderAfter(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+			}), func(w http.ResponseWriter, reason Reason) {
+				events = append(events, "render")
+				tc.render(w, reason)
+			}, func(_ *http.Request, reason Reason) {
+				events = append(events, fmt.Sprintf("after %d", reason.Status))
+			})
+
+			var recovered interface{}
+			func() {
+				defer func() {
+					recovered = recover()
+				}()
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			if got := recovered != nil; got != tc.wantRecover {
+				t.Errorf("GracefullyRenderAfter(): renderer panic propagated: %v, want: %v", got, tc.wantRecover)
+			}
+			if diff := cmp.Diff(tc.wantEvents, events); diff != "" {
+				t.Errorf("GracefullyRenderAfter(): events mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestServeMux(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panicky", func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+	})
+	mux.HandleFunc("/fine", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "Looks good!")
+	})
+	handler := ServeMux(mux, AsText)
+	for tn, tc := range map[string]struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		"panicking route": {
+			path:       "/panicky",
+			wantStatus: http.StatusTeapot,
+			wantBody:   "rut-ro raggy\n",
+		},
+		"other route": {
+			path:       "/fine",
+			wantStatus: http.StatusOK,
+			wantBody:   "Looks good!\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("ServeMux(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("ServeMux(): body got: %q, want: %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestRenderBytes(t *testing.T) {
+	status, header, body := RenderBytes(Because(errors.New("this is an error"),
+		WithStatus(420),
+		WithExplanation("Chill, man!"),
+		WithHeader("Retry-After", "120")), AsJSON)
+	if status != 420 {
+		t.Errorf("RenderBytes(): status got: %v, want: %v", status, 420)
+	}
+	wantBody := `{"error":"this is an error","explanation":"Chill, man!","status":420}` + "\n"
+	if got := string(body); got != wantBody {
+		t.Errorf("RenderBytes():\n got:%v\nwant:%v\n", got, wantBody)
+	}
+	wantHeader := http.Header{
+		"Content-Type":           {"application/json; charset=utf-8"},
+		"Content-Length":         {fmt.Sprint(len(wantBody))},
+		"X-Content-Type-Options": {"nosniff"},
+		"Retry-After":            {"120"},
+	}
+	if diff := cmp.Diff(wantHeader, header); diff != "" {
+		t.Errorf("RenderBytes(): header mismatch (-want +got):\n%v", diff)
+	}
+}
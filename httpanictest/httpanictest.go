@@ -0,0 +1,57 @@
+// Package httpanictest provides utilities for testing code which renders
+// Reasons for panicking, in the spirit of net/http/httptest.
+package httpanictest
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// CaptureWriter is an http.ResponseWriter which captures the response written
+// to it, so that tests may make assertions about it.
+type CaptureWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// NewCaptureWriter returns an empty CaptureWriter.
+func NewCaptureWriter() *CaptureWriter {
+	return &CaptureWriter{header: make(http.Header)}
+}
+
+// Header returns the response headers.
+func (w *CaptureWriter) Header() http.Header {
+	return w.header
+}
+
+// Write captures b as part of the response body. If WriteHeader has not yet
+// been called, the status is 200 OK, as it would be for net/http.
+func (w *CaptureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// WriteHeader captures status, unless a status was already captured.
+func (w *CaptureWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+}
+
+// Status returns the status of the response. If nothing has been written, it is
+// 200 OK, as it would be for net/http.
+func (w *CaptureWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Body returns the response body written so far.
+func (w *CaptureWriter) Body() []byte {
+	return w.body.Bytes()
+}
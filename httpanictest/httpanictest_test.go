@@ -0,0 +1,61 @@
+package httpanictest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cfunkhouser/httpanic"
+)
+
+func TestCaptureWriter(t *testing.T) {
+	w := NewCaptureWriter()
+	httpanic.AsJSON(w, httpanic.Because(errors.New("this is an error"),
+		httpanic.WithStatus(http.StatusTeapot),
+		httpanic.WithExplanation("Chill, man!")))
+
+	if got := w.Status(); got != http.StatusTeapot {
+		t.Errorf("CaptureWriter.Status(): got: %v, want: %v", got, http.StatusTeapot)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("CaptureWriter.Header(): Content-Type got: %q", got)
+	}
+	want := `{"error":"this is an error","explanation":"Chill, man!","status":418}` + "\n"
+	if got := string(w.Body()); got != want {
+		t.Errorf("CaptureWriter.Body():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestCaptureWriterDefaults(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		write    func(http.ResponseWriter)
+		wantBody string
+	}{
+		"nothing written": {
+			write: func(http.ResponseWriter) {},
+		},
+		"body without status": {
+			write: func(w http.ResponseWriter) {
+				w.Write([]byte("Looks good!"))
+			},
+			wantBody: "Looks good!",
+		},
+		"superfluous status": {
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusOK)
+				w.WriteHeader(http.StatusTeapot)
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			w := NewCaptureWriter()
+			tc.write(w)
+			if got := w.Status(); got != http.StatusOK {
+				t.Errorf("CaptureWriter.Status(): got: %v, want: %v", got, http.StatusOK)
+			}
+			if got := string(w.Body()); got != tc.wantBody {
+				t.Errorf("CaptureWriter.Body(): got: %q, want: %q", got, tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package httpanic
+
+import "net/http"
+
+// Incident returns a Renderer for keeping the details of server errors out of
+// responses. For each Reason with a 5xx status, it generates an incident ID
+// using generate, and passes the ID and the Reason to log, which should record
+// the full detail of the Reason somewhere it can later be found by the ID. The
+// client is sent only the ID, as the JSON object {"incident":"<id>"}, along
+// with the status and Headers of the Reason. Reasons with any other status are
+// rendered by render, unchanged.
+func Incident(generate func() string, log func(string, Reason), render Renderer) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		if reason.Status < http.StatusInternalServerError {
+			render(w, reason)
+			return
+		}
+		id := generate()
+		log(id, reason)
+		reason.Body = nil
+		prelude(w, reason)
+		writeJSON(w, reason.Status, incident{ID: id})
+	}
+}
+
+// incident is the client-facing representation of a Reason rendered by
+// Incident.
+type incident struct {
+	ID string `json:"incident"`
+}
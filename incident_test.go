@@ -0,0 +1,61 @@
+package httpanic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIncident(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason     Reason
+		wantBody   string
+		wantLogged bool
+	}{
+		"server error": {
+			reason: Because(errForTesting,
+				WithStatus(http.StatusBadGateway),
+				WithExplanation("Chill, man!"),
+				WithBodyType("text/plain", []byte("secret details"))),
+			wantBody:   `{"incident":"abc123"}` + "\n",
+			wantLogged: true,
+		},
+		"client error": {
+			reason:   Because(errForTesting, WithStatus(http.StatusTeapot)),
+			wantBody: "rut-ro raggy\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var (
+				loggedID     string
+				loggedReason Reason
+			)
+			render := Incident(func() string {
+				return "abc123"
+			}, func(id string, reason Reason) {
+				loggedID, loggedReason = id, reason
+			}, AsText)
+
+			rec := httptest.NewRecorder()
+			render(rec, tc.reason)
+			if rec.Code != tc.reason.Status {
+				t.Errorf("Incident(): status got: %v, want: %v", rec.Code, tc.reason.Status)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("Incident(): body got: %q, want: %q", got, tc.wantBody)
+			}
+			if !tc.wantLogged {
+				if loggedID != "" {
+					t.Errorf("Incident(): logged incident %q, want none", loggedID)
+				}
+				return
+			}
+			if loggedID != "abc123" {
+				t.Errorf("Incident(): logged ID got: %q, want: %q", loggedID, "abc123")
+			}
+			if loggedReason.Explanation != tc.reason.Explanation {
+				t.Errorf("Incident(): logged explanation got: %q, want: %q", loggedReason.Explanation, tc.reason.Explanation)
+			}
+		})
+	}
+}
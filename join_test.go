@@ -0,0 +1,20 @@
+//go:build go1.20
+// +build go1.20
+
+package httpanic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBecauseAllIs(t *testing.T) {
+	errFirst := errors.New("widget 1 not found")
+	errSecond := errors.New("disk full")
+	got := BecauseAll([]error{errFirst, errSecond})
+	for _, want := range []error{errFirst, errSecond} {
+		if !errors.Is(got, want) {
+			t.Errorf("BecauseAll(): errors.Is(%v) got: false, want: true", want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package httpanic
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// jsonAPIError is the JSON:API error object representation of a Reason.
+type jsonAPIError struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+// AsJSONAPI renders a Reason for panicking as a JSON:API error document, with
+// an error object for the Reason in its top-level "errors" array, followed by
+// one for each of its SubProblems. As the specification requires, the status of
+// an error object is a string. Its title is the Title of the Reason, or the
+// text for the status if it has none, and its detail is the Explanation if
+// there is one, or the error message otherwise. If any errors are encountered
+// during render, this function will panic.
+func AsJSONAPI(w http.ResponseWriter, reason Reason) {
+	if prelude(w, reason) {
+		return
+	}
+	doc := struct {
+		Errors []jsonAPIError `json:"errors"`
+	}{
+		Errors: []jsonAPIError{jsonAPIErrorFrom(reason)},
+	}
+	for _, sub := range reason.SubProblems {
+		doc.Errors = append(doc.Errors, jsonAPIErrorFrom(sub))
+	}
+	respond(w, reason.Status, "application/vnd.api+json", func(b *bytes.Buffer) error {
+		return json.NewEncoder(b).Encode(doc)
+	})
+}
+
+// jsonAPIErrorFrom derives a JSON:API error object from a Reason.
+func jsonAPIErrorFrom(reason Reason) jsonAPIError {
+	detail := reason.Explanation
+	if detail == "" {
+		detail = reason.clientError()
+	}
+	return jsonAPIError{
+		Status: strconv.Itoa(reason.Status),
+		Title:  reason.title(),
+		Detail: detail,
+		Code:   reason.Code,
+	}
+}
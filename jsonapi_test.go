@@ -0,0 +1,71 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAsJSONAPI(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   string
+	}{
+		"all members": {
+			reason: Because(errors.New("widget not found"),
+				WithStatus(http.StatusNotFound),
+				WithExplanation("No widget by that name."),
+				WithCode("widget_not_found")),
+			want: `{"errors":[{"status":"404","title":"Not Found","detail":"No widget by that name.","code":"widget_not_found"}]}` + "\n",
+		},
+		"error only": {
+			reason: Because(errors.New("widget not found"), WithStatus(http.StatusNotFound)),
+			want:   `{"errors":[{"status":"404","title":"Not Found","detail":"widget not found"}]}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsJSONAPI(rec, tc.reason)
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("AsJSONAPI(): status got: %v, want: %v", rec.Code, http.StatusNotFound)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/vnd.api+json" {
+				t.Errorf("AsJSONAPI(): Content-Type got: %q", got)
+			}
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsJSONAPI():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+			var doc struct {
+				Errors []map[string]interface{} `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("AsJSONAPI(): body does not unmarshal: %v", err)
+			}
+			if len(doc.Errors) != 1 {
+				t.Fatalf("AsJSONAPI(): got %d errors, want 1", len(doc.Errors))
+			}
+			if _, ok := doc.Errors[0]["status"].(string); !ok {
+				t.Errorf("AsJSONAPI(): status got: %T, want: string", doc.Errors[0]["status"])
+			}
+		})
+	}
+}
+
+func TestAsJSONAPISubProblems(t *testing.T) {
+	reason := Because(errors.New("some widgets were not saved"),
+		WithStatus(http.StatusUnprocessableEntity),
+		WithSubProblem(Because(errors.New("widget 1 not found"), WithStatus(http.StatusNotFound))),
+		WithSubProblem(Because(errors.New("widget 2 is locked"), WithStatus(http.StatusConflict), WithCode("locked"))))
+	want := `{"errors":[` +
+		`{"status":"422","title":"Unprocessable Entity","detail":"some widgets were not saved"},` +
+		`{"status":"404","title":"Not Found","detail":"widget 1 not found"},` +
+		`{"status":"409","title":"Conflict","detail":"widget 2 is locked","code":"locked"}` +
+		`]}` + "\n"
+	rec := httptest.NewRecorder()
+	AsJSONAPI(rec, reason)
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsJSONAPI():\n got:%v\nwant:%v\n", got, want)
+	}
+}
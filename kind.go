@@ -0,0 +1,45 @@
+package httpanic
+
+// Kind is the coarse class of a Reason, derived from its status.
+type Kind int
+
+const (
+	// KindUnknown is the Kind of a Reason whose status is not a valid HTTP
+	// status.
+	KindUnknown Kind = iota
+	// KindInformational is the Kind of a Reason with a 1xx status.
+	KindInformational
+	// KindSuccess is the Kind of a Reason with a 2xx status.
+	KindSuccess
+	// KindRedirect is the Kind of a Reason with a 3xx status.
+	KindRedirect
+	// KindClientError is the Kind of a Reason with a 4xx status.
+	KindClientError
+	// KindServerError is the Kind of a Reason with a 5xx status.
+	KindServerError
+)
+
+var kindNames = [...]string{
+	KindUnknown:       "unknown",
+	KindInformational: "informational",
+	KindSuccess:       "success",
+	KindRedirect:      "redirect",
+	KindClientError:   "client error",
+	KindServerError:   "server error",
+}
+
+// String returns a name for the Kind, suitable for logging.
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return kindNames[KindUnknown]
+	}
+	return kindNames[k]
+}
+
+// Kind returns the class of the Reason's status.
+func (r Reason) Kind() Kind {
+	if r.Status < 100 || r.Status > 599 {
+		return KindUnknown
+	}
+	return Kind(r.Status / 100)
+}
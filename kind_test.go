@@ -0,0 +1,69 @@
+package httpanic
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReasonKind(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		status   int
+		want     Kind
+		wantName string
+	}{
+		"informational": {
+			status:   http.StatusEarlyHints,
+			want:     KindInformational,
+			wantName: "informational",
+		},
+		"success": {
+			status:   http.StatusNoContent,
+			want:     KindSuccess,
+			wantName: "success",
+		},
+		"redirect": {
+			status:   http.StatusSeeOther,
+			want:     KindRedirect,
+			wantName: "redirect",
+		},
+		"client error": {
+			status:   http.StatusTeapot,
+			want:     KindClientError,
+			wantName: "client error",
+		},
+		"server error": {
+			status:   http.StatusBadGateway,
+			want:     KindServerError,
+			wantName: "server error",
+		},
+		"lowest in class": {
+			status:   400,
+			want:     KindClientError,
+			wantName: "client error",
+		},
+		"highest in class": {
+			status:   599,
+			want:     KindServerError,
+			wantName: "server error",
+		},
+		"zero": {
+			want:     KindUnknown,
+			wantName: "unknown",
+		},
+		"out of range": {
+			status:   600,
+			want:     KindUnknown,
+			wantName: "unknown",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got := Status(tc.status).Kind()
+			if got != tc.want {
+				t.Errorf("Reason.Kind(): got: %v, want: %v", got, tc.want)
+			}
+			if got.String() != tc.wantName {
+				t.Errorf("Kind.String(): got: %q, want: %q", got.String(), tc.wantName)
+			}
+		})
+	}
+}
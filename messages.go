@@ -0,0 +1,53 @@
+package httpanic
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// MessageMap maps errors to friendly messages to present to clients in their
+// place. The zero value is an empty MessageMap, ready to use. It is safe for
+// concurrent use.
+type MessageMap struct {
+	mu      sync.RWMutex
+	entries []messageEntry
+}
+
+type messageEntry struct {
+	err     error
+	message string
+}
+
+// Register message as the friendly message for err, and any error which wraps
+// it. If an error matches more than one registered error, the message
+// registered first is used.
+func (m *MessageMap) Register(err error, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, messageEntry{err: err, message: message})
+}
+
+// message returns the friendly message for err, if there is one.
+func (m *MessageMap) message(err error) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, e := range m.entries {
+		if errors.Is(err, e.err) {
+			return e.message, true
+		}
+	}
+	return "", false
+}
+
+// FriendlyMessages wraps render, presenting the friendly message from m to the
+// client in place of the error message of each Reason whose error is one
+// registered in m. The original error is kept on the Reason, for logging.
+func FriendlyMessages(m *MessageMap, render Renderer) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		if msg, ok := m.message(reason.error); ok {
+			reason.clientMessage = msg
+		}
+		render(w, reason)
+	}
+}
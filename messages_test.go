@@ -0,0 +1,52 @@
+package httpanic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFriendlyMessages(t *testing.T) {
+	errNoWidget := errors.New("sql: no rows in result set")
+	errLocked := errors.New("widget row locked")
+	var m MessageMap
+	m.Register(errNoWidget, "We couldn't find that widget.")
+	m.Register(errLocked, "Someone else is editing that widget.")
+	m.Register(errors.New("never matched"), "Never shown.")
+
+	for tn, tc := range map[string]struct {
+		err  error
+		want string
+	}{
+		"registered": {
+			err:  errNoWidget,
+			want: `{"error":"We couldn't find that widget.","status":404}` + "\n",
+		},
+		"wrapped": {
+			err:  fmt.Errorf("loading widget 1: %w", errLocked),
+			want: `{"error":"Someone else is editing that widget.","status":404}` + "\n",
+		},
+		"unregistered": {
+			err:  errForTesting,
+			want: `{"error":"rut-ro raggy","status":404}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var logged string
+			render := FriendlyMessages(&m, func(w http.ResponseWriter, reason Reason) {
+				logged = reason.Error()
+				AsJSON(w, reason)
+			})
+			rec := httptest.NewRecorder()
+			render(rec, Because(tc.err, WithStatus(http.StatusNotFound)))
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("FriendlyMessages():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+			if logged != tc.err.Error() {
+				t.Errorf("FriendlyMessages(): original error got: %q, want: %q", logged, tc.err.Error())
+			}
+		})
+	}
+}
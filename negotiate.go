@@ -0,0 +1,125 @@
+package httpanic
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultMediaType is rendered when the client has no preference, or prefers
+// nothing supported.
+const defaultMediaType = "application/json"
+
+var (
+	// negotiableMu guards negotiable.
+	negotiableMu sync.RWMutex
+
+	// negotiable Renderers, by the media type they produce.
+	negotiable = map[string]Renderer{
+		"application/json":         AsJSON,
+		"application/problem+json": AsProblemJSON,
+		"application/problem+xml":  AsProblemXML,
+		"application/xml":          AsProblemXML,
+		"text/plain":               AsText,
+	}
+)
+
+// RegisterRenderer makes render available to Negotiate, for clients which
+// accept contentType. It replaces any Renderer already registered for
+// contentType, including the built-in ones. Parameters of contentType, like
+// charset, are ignored; render is expected to set the full Content-Type
+// itself.
+func RegisterRenderer(contentType string, render Renderer) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = strings.ToLower(contentType)
+	}
+	negotiableMu.Lock()
+	defer negotiableMu.Unlock()
+	negotiable[mt] = render
+}
+
+// RenderersByType returns the Renderers available to Negotiate, by the media
+// type they produce. The map is a copy, so modifying it has no effect on
+// Negotiate; use RegisterRenderer for that.
+func RenderersByType() map[string]Renderer {
+	negotiableMu.RLock()
+	defer negotiableMu.RUnlock()
+	renderers := make(map[string]Renderer, len(negotiable))
+	for mt, render := range negotiable {
+		renderers[mt] = render
+	}
+	return renderers
+}
+
+// Negotiate renders a Reason for panicking in whichever format the client
+// prefers, according to the Accept header of the request. Of the media types
+// supported, the one the client gives the highest quality value is chosen,
+// with JSON preferred on ties. Media types using the RFC 6839 structured
+// syntax suffixes +json and +xml are treated as application/json and
+// application/xml, respectively. If the client accepts anything, or nothing
+// supported, the Reason is rendered as JSON. More media types may be supported
+// using RegisterRenderer.
+func Negotiate(w http.ResponseWriter, r *http.Request, reason Reason) {
+	w.Header().Add("Vary", "Accept")
+	negotiableMu.RLock()
+	render := negotiable[negotiate(r.Header.Get("Accept"))]
+	negotiableMu.RUnlock()
+	render(w, reason)
+}
+
+// negotiate chooses the supported media type the client most prefers. The
+// caller must hold negotiableMu.
+func negotiate(accept string) string {
+	best, bestQ := defaultMediaType, 0.0
+	for _, mr := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(mr)
+		if err != nil {
+			continue
+		}
+		supported := supportedMediaType(mt)
+		if supported == "" {
+			continue
+		}
+		q := quality(params)
+		if q > bestQ || (q == bestQ && supported == defaultMediaType) {
+			best, bestQ = supported, q
+		}
+	}
+	return best
+}
+
+// quality is the value of the q parameter of a media range, which defaults to
+// 1 when missing, and is treated as 0 when malformed.
+func quality(params map[string]string) float64 {
+	v, ok := params["q"]
+	if !ok {
+		return 1
+	}
+	q, err := strconv.ParseFloat(v, 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0
+	}
+	return q
+}
+
+// supportedMediaType returns the supported media type satisfying the media
+// range mt, or the empty string if there isn't one.
+func supportedMediaType(mt string) string {
+	if _, ok := negotiable[mt]; ok {
+		return mt
+	}
+	switch {
+	case mt == "*/*", mt == "application/*":
+		return defaultMediaType
+	case mt == "text/*":
+		return "text/plain"
+	case strings.HasSuffix(mt, "+json"):
+		return "application/json"
+	case strings.HasSuffix(mt, "+xml"):
+		return "application/xml"
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package httpanic
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		accept string
+		want   string
+	}{
+		"no accept header": {
+			want: "application/json; charset=utf-8",
+		},
+		"anything": {
+			accept: "*/*",
+			want:   "application/json; charset=utf-8",
+		},
+		"json": {
+			accept: "application/json",
+			want:   "application/json; charset=utf-8",
+		},
+		"problem json": {
+			accept: "application/problem+json",
+			want:   "application/problem+json; charset=utf-8",
+		},
+		"vendor json suffix": {
+			accept: "application/vnd.foo+json",
+			want:   "application/json; charset=utf-8",
+		},
+		"vendor json suffix with parameters": {
+			accept: "application/vnd.foo+json; version=2",
+			want:   "application/json; charset=utf-8",
+		},
+		"xml": {
+			accept: "application/xml",
+			want:   "application/problem+xml; charset=utf-8",
+		},
+		"vendor xml suffix": {
+			accept: "application/vnd.foo+xml",
+			want:   "application/problem+xml; charset=utf-8",
+		},
+		"text": {
+			accept: "text/plain",
+			want:   "text/plain; charset=utf-8",
+		},
+		"first supported wins": {
+			accept: "image/png, text/plain, application/problem+xml",
+			want:   "text/plain; charset=utf-8",
+		},
+		"highest quality wins": {
+			accept: "application/xml;q=0.5, application/json;q=0.9",
+			want:   "application/json; charset=utf-8",
+		},
+		"highest quality wins regardless of order": {
+			accept: "application/json;q=0.2, text/plain;q=0.3, application/xml;q=0.1",
+			want:   "text/plain; charset=utf-8",
+		},
+		"default quality is one": {
+			accept: "application/json;q=0.9, application/xml",
+			want:   "application/problem+xml; charset=utf-8",
+		},
+		"json preferred on ties": {
+			accept: "text/plain;q=0.8, application/json;q=0.8",
+			want:   "application/json; charset=utf-8",
+		},
+		"anything preferred over specific type": {
+			accept: "text/plain;q=0.1, */*",
+			want:   "application/json; charset=utf-8",
+		},
+		"specific type preferred over anything": {
+			accept: "text/plain, */*;q=0.1",
+			want:   "text/plain; charset=utf-8",
+		},
+		"type wildcard": {
+			accept: "text/*",
+			want:   "text/plain; charset=utf-8",
+		},
+		"not acceptable": {
+			accept: "text/plain;q=0, image/png",
+			want:   "application/json; charset=utf-8",
+		},
+		"malformed quality": {
+			accept: "text/plain;q=high, application/xml;q=0.1",
+			want:   "application/problem+xml; charset=utf-8",
+		},
+		"nothing supported": {
+			accept: "image/png, image/webp;q=0.5",
+			want:   "application/json; charset=utf-8",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			Negotiate(rec, req, Because(errForTesting, WithStatus(http.StatusNotFound)))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("Negotiate(): status got: %v, want: %v", rec.Code, http.StatusNotFound)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tc.want {
+				t.Errorf("Negotiate(): Content-Type got: %q, want: %q", got, tc.want)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Negotiate(): Vary got: %q, want: %q", got, "Accept")
+			}
+		})
+	}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	RegisterRenderer("text/csv; charset=utf-8", func(w http.ResponseWriter, reason Reason) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(reason.Status)
+		fmt.Fprintf(w, "status,error\n%d,%v\n", reason.Status, reason)
+	})
+	t.Cleanup(func() {
+		negotiableMu.Lock()
+		defer negotiableMu.Unlock()
+		delete(negotiable, "text/csv")
+	})
+
+	renderers := RenderersByType()
+	if _, ok := renderers["text/csv"]; !ok {
+		t.Errorf("RenderersByType(): missing registered text/csv, got: %v", renderers)
+	}
+	delete(renderers, "application/json")
+	if _, ok := RenderersByType()["application/json"]; !ok {
+		t.Errorf("RenderersByType(): modifying the result unregistered application/json")
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json;q=0.5, text/csv")
+	Negotiate(rec, req, Because(errForTesting, WithStatus(http.StatusNotFound)))
+	if got := rec.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Negotiate(): Content-Type got: %q, want: %q", got, "text/csv; charset=utf-8")
+	}
+	if got, want := rec.Body.String(), "status,error\n404,rut-ro raggy\n"; got != want {
+		t.Errorf("Negotiate(): body got: %q, want: %q", got, want)
+	}
+}
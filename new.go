@@ -0,0 +1,217 @@
+package httpanic
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Option configures the middleware returned by New. Options are applied in
+// order, so where several configure the same thing, the last of them wins.
+type Option func(*middleware)
+
+// New returns middleware which handles any Reason to panic gracefully, as
+// configured by opts. Without any, it behaves like Gracefully: errors and
+// strings are converted to Reasons by Because, and Reasons are rendered by
+// TextStatusRenderer, without logging or debug enrichment.
+//
+// Where middleware from this package is nested, as when every route is wrapped
+// by Gracefully and some are wrapped again, only the outermost layer recovers,
+// but the configuration of every layer a panic passed through takes effect.
+// Where several of them set the Renderer, Reasoner or fallback, the innermost
+// wins, since it is the closest to the handler which panicked. Their loggers
+// and functions to call on panic and after rendering all apply, those of outer
+// layers first, and debug enrichment is enabled if any of them enables it.
+// Loggers do not log a Reason a second time.
+func New(opts ...Option) func(http.Handler) http.Handler {
+	var m middleware
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m.handler
+}
+
+// WithRenderer sets the Renderer used to render each Reason.
+func WithRenderer(render Renderer) Option {
+	return WithRequestRenderer(IgnoreRequest(render))
+}
+
+// WithRequestRenderer sets the RequestRenderer used to render each Reason.
+func WithRequestRenderer(render RequestRenderer) Option {
+	return func(m *middleware) {
+		m.render = render
+	}
+}
+
+// WithReasoner sets the Reasoner used to convert errors and strings given to
+// panic to Reasons.
+func WithReasoner(cuz Reasoner) Option {
+	return WithRequestReasoner(withoutRequest(cuz))
+}
+
+// WithRequestReasoner sets the RequestReasoner used to convert errors and
+// strings given to panic to Reasons, so that they may be classified according
+// to the request.
+func WithRequestReasoner(cuz RequestReasoner) Option {
+	return func(m *middleware) {
+		m.cuz = cuz
+	}
+}
+
+// WithLogger logs each Reason to logger before it is rendered, as
+// GracefullyRenderErrorLog does.
+func WithLogger(logger *log.Logger) Option {
+	return func(m *middleware) {
+		m.logger = logger
+	}
+}
+
+// WithFallback sets a Renderer to fall back on when the Renderer panics, so
+// that a Reason which cannot be rendered, perhaps because its Metadata cannot
+// be marshaled, still produces a response. A fallback as simple as StatusOnly
+// is best. If the fallback panics too, the panic is not recovered from.
+func WithFallback(render Renderer) Option {
+	return func(m *middleware) {
+		m.fallback = render
+	}
+}
+
+// WithOnSuccess sets a function to be called after the wrapped handler has
+// served a request without panicking, as GracefullyRenderOnSuccess does.
+func WithOnSuccess(onSuccess func(*http.Request)) Option {
+	return func(m *middleware) {
+		m.onSuccess = onSuccess
+	}
+}
+
+// WithDebug enables debug enrichment for every Reason rendered, as if Debug
+// were true, when debug is true. When it is false, Debug still applies.
+func WithDebug(debug bool) Option {
+	return func(m *middleware) {
+		m.debug = debug
+	}
+}
+
+// WithOnPanic sets a function to be called with each recovered Reason, and the
+// request being served, just before the Reason is rendered. It is useful for
+// counting panics, for example.
+func WithOnPanic(onPanic func(*http.Request, Reason)) Option {
+	return func(m *middleware) {
+		m.onPanic = onPanic
+	}
+}
+
+// WithAfterRender sets a function to be called with each recovered Reason, and
+// the request being served, once the Reason has been rendered, as
+// GracefullyRenderAfter does. It is called even if rendering panics, in which
+// case that panic continues once after returns.
+func WithAfterRender(after func(*http.Request, Reason)) Option {
+	return func(m *middleware) {
+		m.after = after
+	}
+}
+
+// configure returns render and cuz as configured by layers, the layers of
+// middleware a panic passed through, outermost first, as described for New.
+// render and cuz are used where none of them sets a Renderer or Reasoner.
+func configure(layers []middleware, render RequestRenderer, cuz RequestReasoner) (RequestRenderer, RequestReasoner) {
+	var fallback Renderer
+	var debug bool
+	for _, m := range layers {
+		if m.render != nil {
+			render = m.render
+		}
+		if m.cuz != nil {
+			cuz = m.cuz
+		}
+		if m.fallback != nil {
+			fallback = m.fallback
+		}
+		debug = debug || m.debug
+	}
+	render = withReasonRenderer(render)
+	if fallback != nil {
+		render = withFallback(render, fallback)
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		m := layers[i]
+		if m.logger != nil {
+			render = withLogger(render, m.logger)
+		}
+		if m.onPanic != nil {
+			render = withOnPanic(render, m.onPanic)
+		}
+		if m.after != nil {
+			render = withAfter(render, m.after)
+		}
+	}
+	if debug {
+		render = debugAll(render)
+	}
+	return render, cuz
+}
+
+// withReasonRenderer wraps render, rendering each Reason with its own Renderer
+// instead, if it has one.
+func withReasonRenderer(render RequestRenderer) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		if reason.Render != nil {
+			reason.Render(w, reason)
+			return
+		}
+		render(w, r, reason)
+	}
+}
+
+// withFallback wraps render, rendering the Reason with fallback instead if
+// render panics.
+func withFallback(render RequestRenderer, fallback Renderer) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		defer func() {
+			if recover() != nil {
+				fallback(w, reason)
+			}
+		}()
+		render(w, r, reason)
+	}
+}
+
+// withLogger wraps render, logging each Reason to logger in the same format
+// net/http uses to log panics it recovers from itself, including the stack of
+// the panicking goroutine. Reasons already marked as logged are not logged
+// again.
+func withLogger(render RequestRenderer, logger *log.Logger) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		if !reason.WasLogged() {
+			logger.Printf("http: panic serving %v: %v\n%s", r.RemoteAddr, reason, debug.Stack())
+			reason = reason.MarkLogged()
+		}
+		render(w, r, reason)
+	}
+}
+
+// withOnPanic wraps render, calling onPanic with each Reason before it is
+// rendered.
+func withOnPanic(render RequestRenderer, onPanic func(*http.Request, Reason)) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		onPanic(r, reason)
+		render(w, r, reason)
+	}
+}
+
+// withAfter wraps render, calling after once each Reason has been rendered,
+// even if render panics.
+func withAfter(render RequestRenderer, after func(*http.Request, Reason)) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		defer after(r, reason)
+		render(w, r, reason)
+	}
+}
+
+// debugAll wraps render, enabling debug enrichment for each Reason.
+func debugAll(render RequestRenderer) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		reason.debug = true
+		render(w, r, reason)
+	}
+}
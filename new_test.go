@@ -0,0 +1,308 @@
+package httpanic
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var logs bytes.Buffer
+	for tn, tc := range map[string]struct {
+		opts       []Option
+		p          interface{}
+		wantStatus int
+		wantBody   string
+		wantLogged bool
+	}{
+		"defaults": {
+			p:          errForTesting,
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error\n",
+		},
+		"renderer and reasoner": {
+			opts: []Option{
+				WithRenderer(AsJSON),
+				WithReasoner(func(e error, deets ...Detail) Reason {
+					return Because(e, append([]Detail{WithStatus(http.StatusBadGateway)}, deets...)...)
+				}),
+			},
+			p:          errForTesting,
+			wantStatus: http.StatusBadGateway,
+			wantBody:   `{"error":"rut-ro raggy","status":502}` + "\n",
+		},
+		"logger": {
+			opts:       []Option{WithRenderer(AsText), WithLogger(log.New(&logs, "", 0))},
+			p:          Because(errForTesting, WithStatus(http.StatusTeapot)),
+			wantStatus: http.StatusTeapot,
+			wantBody:   "rut-ro raggy\n",
+			wantLogged: true,
+		},
+		"fallback": {
+			opts: []Option{
+				WithRenderer(AsProblemJSON),
+				WithFallback(StatusOnly),
+				WithLogger(log.New(&logs, "", 0)),
+			},
+			p:          Because(errForTesting, WithStatus(http.StatusTeapot), WithMetadata("unmarshalable", func() {})),
+			wantStatus: http.StatusTeapot,
+			wantLogged: true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			logs.Reset()
+			handler := New(tc.opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tc.p)
+			}))
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Errorf("New(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("New():\n got:%q\nwant:%q\n", got, tc.wantBody)
+			}
+			wantPrefix := "http: panic serving " + req.RemoteAddr + ": rut-ro raggy\n"
+			if got := strings.HasPrefix(logs.String(), wantPrefix); got != tc.wantLogged {
+				t.Errorf("New(): logged got: %v, want: %v, log: %q", got, tc.wantLogged, logs.String())
+			}
+		})
+	}
+}
+
+func TestNewNested(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.New(&logs, "", 0)
+	for tn, tc := range map[string]struct {
+		outer, inner    []Option
+		panicAfterInner bool
+		wantStatus      int
+		wantContentType string
+		wantLogged      int
+	}{
+		"inner logger and reasoner": {
+			inner:           []Option{WithReasoner(BecauseFS), WithRenderer(AsJSON), WithLogger(logger)},
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "application/json; charset=utf-8",
+			wantLogged:      1,
+		},
+		"outer logger and inner renderer": {
+			outer:           []Option{WithReasoner(BecauseFS), WithLogger(logger)},
+			inner:           []Option{WithRenderer(AsJSON)},
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "application/json; charset=utf-8",
+			wantLogged:      1,
+		},
+		"innermost renderer wins": {
+			outer:           []Option{WithRenderer(AsJSON)},
+			inner:           []Option{WithRenderer(AsText)},
+			wantStatus:      http.StatusInternalServerError,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		"logged once": {
+			outer:           []Option{WithLogger(logger)},
+			inner:           []Option{WithReasoner(BecauseFS), WithLogger(logger)},
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "text/plain; charset=utf-8",
+			wantLogged:      1,
+		},
+		"panic after inner returns": {
+			outer:           []Option{WithRenderer(AsText)},
+			inner:           []Option{WithReasoner(BecauseFS), WithRenderer(AsJSON), WithLogger(logger)},
+			panicAfterInner: true,
+			wantStatus:      http.StatusInternalServerError,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			logs.Reset()
+			inner := New(tc.inner...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				if !tc.panicAfterInner {
+					panic(fs.ErrNotExist)
+				}
+			}))
+			handler := New(tc.outer...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				inner.ServeHTTP(w, r)
+				panic(fs.ErrNotExist)
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("New(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tc.wantContentType {
+				t.Errorf("New(): Content-Type got: %q, want: %q", got, tc.wantContentType)
+			}
+			if got := strings.Count(logs.String(), "http: panic serving "); got != tc.wantLogged {
+				t.Errorf("New(): logged got: %v times, want: %v, log: %q", got, tc.wantLogged, logs.String())
+			}
+		})
+	}
+}
+
+func TestNewOnSuccess(t *testing.T) {
+	var succeeded bool
+	handler := New(WithOnSuccess(func(*http.Request) {
+		succeeded = true
+	}))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !succeeded {
+		t.Errorf("New(): onSuccess not called")
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("New(): status got: %v, want: %v", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestNewFallbackPanics(t *testing.T) {
+	errFallback := errors.New("fallback failed")
+	defer func() {
+		if got := recover(); got != errFallback {
+			t.Errorf("New(): recovered got: %v, want: %v", got, errFallback)
+		}
+	}()
+	New(WithRenderer(func(http.ResponseWriter, Reason) {
+		panic("render failed")
+	}), WithFallback(func(http.ResponseWriter, Reason) {
+		panic(errFallback)
+	}))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(errForTesting)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// panicTeapot is a named handler function, which panics with a Reason.
+func panicTeapot(http.ResponseWriter, *http.Request) {
+	panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+}
+
+func TestNewOptions(t *testing.T) {
+	var onPanicReason, afterReason Reason
+	for tn, tc := range map[string]struct {
+		opts        []Option
+		wantStatus  int
+		wantBody    string
+		wantOnPanic bool
+		wantAfter   bool
+	}{
+		"defaults": {
+			wantStatus: http.StatusTeapot,
+			wantBody:   "I'm a teapot\n",
+		},
+		"last renderer wins": {
+			opts:       []Option{WithRenderer(AsText), WithRenderer(AsJSON)},
+			wantStatus: http.StatusTeapot,
+			wantBody:   `{"error":"rut-ro raggy","status":418}` + "\n",
+		},
+		"debug": {
+			opts:       []Option{WithRequestRenderer(AsJSONRequest), WithDebug(true)},
+			wantStatus: http.StatusTeapot,
+			wantBody:   `{"error":"rut-ro raggy","status":418,"method":"GET","path":"/widgets","source":"github.com/cfunkhouser/httpanic.panicTeapot"}` + "\n",
+		},
+		"debug disabled by later option": {
+			opts:       []Option{WithRequestRenderer(AsJSONRequest), WithDebug(true), WithDebug(false)},
+			wantStatus: http.StatusTeapot,
+			wantBody:   `{"error":"rut-ro raggy","status":418}` + "\n",
+		},
+		"on panic": {
+			opts: []Option{WithOnPanic(func(_ *http.Request, reason Reason) {
+				onPanicReason = reason
+			})},
+			wantStatus:  http.StatusTeapot,
+			wantBody:    "I'm a teapot\n",
+			wantOnPanic: true,
+		},
+		"after render": {
+			opts: []Option{WithAfterRender(func(_ *http.Request, reason Reason) {
+				afterReason = reason
+			})},
+			wantStatus: http.StatusTeapot,
+			wantBody:   "I'm a teapot\n",
+			wantAfter:  true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			onPanicReason, afterReason = Reason{}, Reason{}
+			handler := New(tc.opts...)(http.HandlerFunc(panicTeapot))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/widgets", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("New(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("New():\n got:%q\nwant:%q\n", got, tc.wantBody)
+			}
+			if got := onPanicReason.Status == http.StatusTeapot; got != tc.wantOnPanic {
+				t.Errorf("New(): onPanic called got: %v, want: %v", got, tc.wantOnPanic)
+			}
+			if got := afterReason.Status == http.StatusTeapot; got != tc.wantAfter {
+				t.Errorf("New(): after called got: %v, want: %v", got, tc.wantAfter)
+			}
+		})
+	}
+}
+
+func TestWithReasonRenderer(t *testing.T) {
+	var logs bytes.Buffer
+	reason := Because(errForTesting, WithStatus(http.StatusTeapot), WithReasonRenderer(AsText))
+	for tn, handler := range map[string]http.Handler{
+		"middleware": New(WithRenderer(AsJSON), WithLogger(log.New(&logs, "", 0)))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(reason)
+		})),
+		"request renderer": GracefullyRenderRequest(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(reason)
+		}), AsJSONRequest),
+		"HandleError": HandleError(func(http.ResponseWriter, *http.Request) error {
+			return reason
+		}, Because, AsJSON),
+	} {
+		t.Run(tn, func(t *testing.T) {
+			logs.Reset()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusTeapot {
+				t.Errorf("WithReasonRenderer(): status got: %v, want: %v", rec.Code, http.StatusTeapot)
+			}
+			if got, want := rec.Body.String(), "rut-ro raggy\n"; got != want {
+				t.Errorf("WithReasonRenderer():\n got:%q\nwant:%q\n", got, want)
+			}
+			if tn == "middleware" && logs.Len() == 0 {
+				t.Errorf("WithReasonRenderer(): Reason not logged by the middleware")
+			}
+		})
+	}
+}
+
+func TestWithReasonRendererPerRequest(t *testing.T) {
+	handler := HandleError(func(_ http.ResponseWriter, r *http.Request) error {
+		if r.URL.Path == "/custom" {
+			return Because(errForTesting, WithStatus(http.StatusTeapot), WithReasonRenderer(AsText))
+		}
+		return errForTesting
+	}, Because, AsJSON)
+	for _, tc := range []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/custom", http.StatusTeapot, "rut-ro raggy\n"},
+		{"/plain", http.StatusInternalServerError, `{"error":"rut-ro raggy","status":500}` + "\n"},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("HandleError(): %v status got: %v, want: %v", tc.path, rec.Code, tc.wantStatus)
+		}
+		if got := rec.Body.String(); got != tc.wantBody {
+			t.Errorf("HandleError(): %v body got: %q, want: %q", tc.path, got, tc.wantBody)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package httpanic
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"sort"
+)
+
+// problem is the RFC 7807 Problem Details representation of a Reason.
+type problem struct {
+	XMLName  xml.Name  `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type     string    `json:"type" xml:"type"`
+	Title    string    `json:"title" xml:"title"`
+	Status   int       `json:"status" xml:"status"`
+	Detail   string    `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string    `json:"instance,omitempty" xml:"instance,omitempty"`
+	Errors   []problem `json:"errors,omitempty" xml:"-"`
+}
+
+// ProblemTypes maps statuses to the URIs identifying their problem types, for
+// AsProblemJSON and AsProblemXML. Statuses without a registered type are
+// rendered with the type "about:blank". It must not be modified while Reasons
+// are being rendered.
+var ProblemTypes = map[int]string{}
+
+// problemFrom derives Problem Details from a Reason. The type is looked up by
+// status in types. The title is the Title of the Reason if it has one, or the
+// text for its status otherwise, so that it stays concise however verbose the
+// error message is. The detail is the Explanation if there is one, or the error
+// message otherwise. Each of the SubProblems of the Reason becomes a problem in
+// the errors member, derived in the same way.
+func problemFrom(reason Reason, types map[int]string) problem {
+	p := problem{
+		Type:     types[reason.Status],
+		Title:    reason.title(),
+		Status:   reason.Status,
+		Detail:   reason.Explanation,
+		Instance: reason.Instance,
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Detail == "" {
+		p.Detail = reason.clientError()
+	}
+	for _, sub := range reason.SubProblems {
+		p.Errors = append(p.Errors, problemFrom(sub, types))
+	}
+	return p
+}
+
+// AsProblemJSON renders a Reason for panicking as an RFC 7807
+// application/problem+json document. The entries of the Reason's Metadata are
+// added as top-level extension members, as RFC 9457 allows, except for any
+// which would replace the members it defines. The SubProblems of the Reason are
+// rendered as an array of problems in the "errors" member, which Metadata may
+// not replace either. If any errors are encountered during render, this
+// function will panic.
+func AsProblemJSON(w http.ResponseWriter, reason Reason) {
+	AsProblemJSONWithTypes(ProblemTypes)(w, reason)
+}
+
+// problemMembers are the members defined by RFC 9457, which Metadata may not
+// replace.
+var problemMembers = map[string]bool{
+	"type":     true,
+	"title":    true,
+	"status":   true,
+	"detail":   true,
+	"instance": true,
+}
+
+// writeExtensionMembers adds the entries of metadata to the JSON object which
+// ends b, followed by a newline, as RFC 9457 extension members. Entries named
+// like the members it defines are dropped, as is any named "errors" if the
+// object has sub-problems. Entries are written in order of their keys.
+func writeExtensionMembers(b *bytes.Buffer, metadata map[string]interface{}, hasErrors bool) error {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		if !problemMembers[k] && !(hasErrors && k == "errors") {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	// Reopen the object, which json.Encoder ended with "}\n".
+	b.Truncate(b.Len() - 2)
+	for _, k := range keys {
+		key, err := json.Marshal(k)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(metadata[k])
+		if err != nil {
+			return err
+		}
+		b.WriteByte(',')
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// AsProblemJSONWithTypes returns a Renderer which behaves like AsProblemJSON,
+// except that problem types are looked up in types instead of ProblemTypes.
+func AsProblemJSONWithTypes(types map[int]string) Renderer {
+	return func(w http.ResponseWriter, reason Reason) {
+		if prelude(w, reason) {
+			return
+		}
+		respond(w, reason.Status, "application/problem+json; charset=utf-8", func(b *bytes.Buffer) error {
+			if err := json.NewEncoder(b).Encode(problemFrom(reason, types)); err != nil {
+				return err
+			}
+			return writeExtensionMembers(b, reason.Metadata, len(reason.SubProblems) > 0)
+		})
+	}
+}
+
+// AsProblemJSONRequest behaves like AsProblemJSON, and additionally refers to
+// the path of the failed request as the problem instance, unless the Reason
+// already has an Instance set using WithInstance.
+func AsProblemJSONRequest(w http.ResponseWriter, r *http.Request, reason Reason) {
+	if reason.Instance == "" {
+		reason.Instance = r.URL.Path
+	}
+	AsProblemJSON(w, reason)
+}
+
+// AsProblemXML renders a Reason for panicking as an RFC 7807
+// application/problem+xml document. The fields are derived exactly as they are
+// for AsProblemJSON, but Metadata and SubProblems are left out. If any errors
+// are encountered during render, this function will panic.
+func AsProblemXML(w http.ResponseWriter, reason Reason) {
+	if prelude(w, reason) {
+		return
+	}
+	respond(w, reason.Status, "application/problem+xml; charset=utf-8", func(b *bytes.Buffer) error {
+		b.WriteString(xml.Header)
+		return xml.NewEncoder(b).Encode(problemFrom(reason, ProblemTypes))
+	})
+}
@@ -0,0 +1,278 @@
+package httpanic
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProblemFrom(t *testing.T) {
+	testErr := errors.New("widget not found")
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   problem
+	}{
+		"error only": {
+			reason: Because(testErr, WithStatus(http.StatusNotFound)),
+			want: problem{
+				Type:   "about:blank",
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+				Detail: "widget not found",
+			},
+		},
+		"verbose error": {
+			reason: Because(fmt.Errorf("loading widget 12345 from shard 7: %w", testErr), WithStatus(http.StatusNotFound)),
+			want: problem{
+				Type:   "about:blank",
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+				Detail: "loading widget 12345 from shard 7: widget not found",
+			},
+		},
+		"explicit title": {
+			reason: Because(testErr, WithStatus(http.StatusNotFound), WithTitle("No Such Widget")),
+			want: problem{
+				Type:   "about:blank",
+				Title:  "No Such Widget",
+				Status: http.StatusNotFound,
+				Detail: "widget not found",
+			},
+		},
+		"explanation preferred over error": {
+			reason: Because(testErr, WithExplanation("No widget by that name.")),
+			want: problem{
+				Type:   "about:blank",
+				Title:  "Internal Server Error",
+				Status: http.StatusInternalServerError,
+				Detail: "No widget by that name.",
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, problemFrom(tc.reason, nil)); diff != "" {
+				t.Errorf("problemFrom(): mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestAsProblemJSON(t *testing.T) {
+	want := `{"type":"about:blank","title":"Not Found","status":404,"detail":"No widget by that name."}` + "\n"
+	rec := httptest.NewRecorder()
+	AsProblemJSON(rec, Because(errors.New("widget not found"),
+		WithStatus(http.StatusNotFound),
+		WithExplanation("No widget by that name.")))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("AsProblemJSON(): status got: %v, want: %v", rec.Code, http.StatusNotFound)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/problem+json; charset=utf-8" {
+		t.Errorf("AsProblemJSON(): Content-Type got: %q", got)
+	}
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsProblemJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestAsProblemJSONWithTypes(t *testing.T) {
+	types := map[int]string{
+		http.StatusNotFound: "https://example.com/problems/not-found",
+	}
+	for tn, tc := range map[string]struct {
+		status int
+		want   string
+	}{
+		"registered": {
+			status: http.StatusNotFound,
+			want:   `{"type":"https://example.com/problems/not-found","title":"Not Found","status":404,"detail":"widget not found"}` + "\n",
+		},
+		"unregistered": {
+			status: http.StatusGone,
+			want:   `{"type":"about:blank","title":"Gone","status":410,"detail":"widget not found"}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsProblemJSONWithTypes(types)(rec, Because(errors.New("widget not found"), WithStatus(tc.status)))
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsProblemJSONWithTypes():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProblemTypes(t *testing.T) {
+	ProblemTypes[http.StatusNotFound] = "https://example.com/problems/not-found"
+	t.Cleanup(func() {
+		delete(ProblemTypes, http.StatusNotFound)
+	})
+	want := `{"type":"https://example.com/problems/not-found","title":"Not Found","status":404,"detail":"widget not found"}` + "\n"
+	rec := httptest.NewRecorder()
+	AsProblemJSON(rec, Because(errors.New("widget not found"), WithStatus(http.StatusNotFound)))
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsProblemJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestAsProblemJSONMetadata(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   string
+	}{
+		"extension members": {
+			reason: Because(errors.New("out of credit"),
+				WithStatus(http.StatusForbidden),
+				WithMetadata("balance", 30),
+				WithMetadata("accounts", []string{"/account/12345", "/account/67890"})),
+			want: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"out of credit","accounts":["/account/12345","/account/67890"],"balance":30}` + "\n",
+		},
+		"standard members not replaced": {
+			reason: Because(errors.New("out of credit"),
+				WithStatus(http.StatusForbidden),
+				WithMetadata("status", 200),
+				WithMetadata("title", "OK"),
+				WithMetadata("balance", 30)),
+			want: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"out of credit","balance":30}` + "\n",
+		},
+		"only standard members": {
+			reason: Because(errors.New("out of credit"),
+				WithStatus(http.StatusForbidden),
+				WithMetadata("detail", "nothing to see here")),
+			want: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"out of credit"}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsProblemJSON(rec, tc.reason)
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsProblemJSON():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+			if got, want := rec.Header().Get("Content-Length"), fmt.Sprint(len(tc.want)); got != want {
+				t.Errorf("AsProblemJSON(): Content-Length got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAsProblemJSONRequest(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   string
+	}{
+		"instance from request": {
+			reason: Because(errors.New("widget not found"), WithStatus(http.StatusNotFound)),
+			want:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"widget not found","instance":"/widgets/1"}` + "\n",
+		},
+		"explicit instance": {
+			reason: Because(errors.New("widget not found"),
+				WithStatus(http.StatusNotFound),
+				WithInstance("/incidents/abc123")),
+			want: `{"type":"about:blank","title":"Not Found","status":404,"detail":"widget not found","instance":"/incidents/abc123"}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/widgets/1?verbose=true", nil)
+			AsProblemJSONRequest(rec, req, tc.reason)
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsProblemJSONRequest():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAsProblemXML(t *testing.T) {
+	rec := httptest.NewRecorder()
+	AsProblemXML(rec, Because(errors.New("widget not found"),
+		WithStatus(http.StatusNotFound),
+		WithExplanation("No widget by that name.")))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("AsProblemXML(): status got: %v, want: %v", rec.Code, http.StatusNotFound)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/problem+xml; charset=utf-8" {
+		t.Errorf("AsProblemXML(): Content-Type got: %q", got)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, xml.Header) {
+		t.Errorf("AsProblemXML(): body missing XML declaration: %v", body)
+	}
+	wantElem := `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>Not Found</title><status>404</status><detail>No widget by that name.</detail></problem>`
+	if got := strings.TrimPrefix(body, xml.Header); got != wantElem {
+		t.Errorf("AsProblemXML():\n got:%v\nwant:%v\n", got, wantElem)
+	}
+
+	var got problem
+	if err := xml.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("AsProblemXML(): body does not unmarshal: %v", err)
+	}
+	want := problem{
+		XMLName: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"},
+		Type:    "about:blank",
+		Title:   "Not Found",
+		Status:  http.StatusNotFound,
+		Detail:  "No widget by that name.",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AsProblemXML(): unmarshaled mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestAsProblemJSONSubProblems(t *testing.T) {
+	reason := Because(errors.New("some widgets were not saved"),
+		WithStatus(http.StatusUnprocessableEntity),
+		WithSubProblem(Because(errors.New("widget 1 not found"), WithStatus(http.StatusNotFound))),
+		WithSubProblem(Because(errors.New("widget 2 is locked"),
+			WithStatus(http.StatusConflict),
+			WithExplanation("Someone else is editing widget 2."),
+			WithInstance("/widgets/2"))),
+		WithMetadata("errors", "not replaced"),
+		WithMetadata("batch", "abc123"))
+	want := `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"some widgets were not saved",` +
+		`"errors":[` +
+		`{"type":"about:blank","title":"Not Found","status":404,"detail":"widget 1 not found"},` +
+		`{"type":"about:blank","title":"Conflict","status":409,"detail":"Someone else is editing widget 2.","instance":"/widgets/2"}` +
+		`],"batch":"abc123"}` + "\n"
+	rec := httptest.NewRecorder()
+	AsProblemJSON(rec, reason)
+	if got := rec.Body.String(); got != want {
+		t.Errorf("AsProblemJSON():\n got:%v\nwant:%v\n", got, want)
+	}
+}
+
+func TestAsProblemJSONTitle(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		reason Reason
+		want   string
+	}{
+		"status text": {
+			reason: Because(errors.New("SELECT * FROM widgets WHERE id = 12345: no rows in result set"), WithStatus(http.StatusNotFound)),
+			want:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"SELECT * FROM widgets WHERE id = 12345: no rows in result set"}` + "\n",
+		},
+		"explanation as detail": {
+			reason: Because(errors.New("SELECT * FROM widgets WHERE id = 12345: no rows in result set"),
+				WithStatus(http.StatusNotFound),
+				WithExplanation("No widget by that ID.")),
+			want: `{"type":"about:blank","title":"Not Found","status":404,"detail":"No widget by that ID."}` + "\n",
+		},
+		"explicit title": {
+			reason: Because(errors.New("widget not found"), WithStatus(http.StatusNotFound), WithTitle("No Such Widget")),
+			want:   `{"type":"about:blank","title":"No Such Widget","status":404,"detail":"widget not found"}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			AsProblemJSON(rec, tc.reason)
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("AsProblemJSON():\n got:%v\nwant:%v\n", got, tc.want)
+			}
+		})
+	}
+}
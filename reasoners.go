@@ -0,0 +1,138 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// BecauseFS is a Reasoner for errors from file systems, such as those returned
+// by os.Open or fs.ReadFile. It behaves like Because, except that the status
+// is 404 Not Found if e is fs.ErrNotExist, and 403 Forbidden if e is
+// fs.ErrPermission. Details given to BecauseFS are applied after the status
+// is chosen, so they may override it.
+func BecauseFS(e error, deets ...Detail) Reason {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(e, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(e, fs.ErrPermission):
+		status = http.StatusForbidden
+	}
+	return Because(e, append([]Detail{WithStatus(status)}, deets...)...)
+}
+
+// BecauseJSON is a Reasoner for errors from decoding JSON sent by the client.
+// It behaves like Because, except that if e is a *json.SyntaxError or a
+// *json.UnmarshalTypeError, the status is 400 Bad Request and the Explanation
+// points at what was wrong with the JSON. Details given to BecauseJSON are
+// applied afterward, so they may override both.
+func BecauseJSON(e error, deets ...Detail) Reason {
+	var (
+		se *json.SyntaxError
+		te *json.UnmarshalTypeError
+		jd []Detail
+	)
+	switch {
+	case errors.As(e, &se):
+		jd = []Detail{
+			WithStatus(http.StatusBadRequest),
+			WithExplanation(fmt.Sprintf("invalid JSON at offset %d", se.Offset)),
+		}
+	case errors.As(e, &te):
+		explanation := fmt.Sprintf("invalid JSON value at offset %d: expected %v", te.Offset, te.Type)
+		if te.Field != "" {
+			explanation = fmt.Sprintf("invalid JSON value for field %q: expected %v", te.Field, te.Type)
+		}
+		jd = []Detail{
+			WithStatus(http.StatusBadRequest),
+			WithExplanation(explanation),
+		}
+	}
+	return Because(e, append(jd, deets...)...)
+}
+
+// BecauseTyped is a Reasoner for rich, typed errors. It behaves like Because,
+// except that the status is taken from e if it is a StatusCoder, and the Code
+// if it is a Coder, as if by WithStatusFromError and WithCodeFromError. Details
+// given to BecauseTyped are applied afterward, so they may override both.
+func BecauseTyped(e error, deets ...Detail) Reason {
+	return Because(e, append([]Detail{WithStatusFromError(), WithCodeFromError()}, deets...)...)
+}
+
+// BecauseHTTP is a Reasoner for errors from packages which carry an HTTP status
+// on their errors, under one of a few common method names. It behaves like
+// Because, except that the status is taken from the first of these methods
+// implemented by e, or by any error it wraps: HTTPStatus() int,
+// StatusCode() int, and Code() int. Since Code is also used for codes which
+// are not HTTP statuses, a status outside of the range 100-599 is ignored.
+// Details given to BecauseHTTP are applied afterward, so they may override the
+// status.
+func BecauseHTTP(e error, deets ...Detail) Reason {
+	var hd []Detail
+	if status, ok := httpStatusOf(e); ok {
+		hd = []Detail{WithStatus(status)}
+	}
+	return Because(e, append(hd, deets...)...)
+}
+
+// httpStatusOf returns the HTTP status carried by e, as described for
+// BecauseHTTP, and whether it carries one.
+func httpStatusOf(e error) (int, bool) {
+	var (
+		hs     interface{ HTTPStatus() int }
+		sc     StatusCoder
+		ic     interface{ Code() int }
+		status int
+	)
+	switch {
+	case errors.As(e, &hs):
+		status = hs.HTTPStatus()
+	case errors.As(e, &sc):
+		status = sc.StatusCode()
+	case errors.As(e, &ic):
+		status = ic.Code()
+	}
+	return status, status >= 100 && status <= 599
+}
+
+// BecauseAll describes the reason we are deciding to panic with several errors
+// at once, as for an aggregate operation in which more than one part failed.
+// It behaves like Because for an error joining errs, whose message has the
+// message of each on its own line, ignoring any which are nil. Each error is
+// also added to the Reason as one of its SubProblems, so that the Renderers
+// which support them present each separately. Details given to BecauseAll are
+// applied afterward.
+func BecauseAll(errs []error, deets ...Detail) Reason {
+	joined := &joinError{}
+	var jd []Detail
+	for _, e := range errs {
+		if e != nil {
+			joined.errs = append(joined.errs, e)
+			jd = append(jd, WithSubProblem(Because(e)))
+		}
+	}
+	return Because(joined, append(jd, deets...)...)
+}
+
+// joinError is an error joining several, like those returned by errors.Join,
+// which is only available from Go 1.20.
+type joinError struct {
+	errs []error
+}
+
+func (e *joinError) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the joined errors, for errors.Is and errors.As from Go 1.20.
+func (e *joinError) Unwrap() []error {
+	return e.errs
+}
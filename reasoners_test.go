@@ -0,0 +1,271 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBecauseFS(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		err   error
+		deets []Detail
+		want  int
+	}{
+		"not exist": {
+			err:  &fs.PathError{Op: "open", Path: "/widgets/1", Err: fs.ErrNotExist},
+			want: http.StatusNotFound,
+		},
+		"permission": {
+			err:  fmt.Errorf("reading widget: %w", &fs.PathError{Op: "open", Path: "/widgets/1", Err: fs.ErrPermission}),
+			want: http.StatusForbidden,
+		},
+		"other I/O error": {
+			err:  &fs.PathError{Op: "read", Path: "/widgets/1", Err: errors.New("input/output error")},
+			want: http.StatusInternalServerError,
+		},
+		"real file system": {
+			err: func() error {
+				_, err := os.Open("testdata/does-not-exist")
+				return err
+			}(),
+			want: http.StatusNotFound,
+		},
+		"status overridden": {
+			err:   fs.ErrNotExist,
+			deets: []Detail{WithStatus(http.StatusGone)},
+			want:  http.StatusGone,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got := BecauseFS(tc.err, tc.deets...)
+			if got.Status != tc.want {
+				t.Errorf("BecauseFS(): status got: %v, want: %v", got.Status, tc.want)
+			}
+			if !errors.Is(got, tc.err) {
+				t.Errorf("BecauseFS(): got: %v, which does not wrap %v", got, tc.err)
+			}
+		})
+	}
+}
+
+func TestBecauseJSON(t *testing.T) {
+	decode := func(body string) error {
+		var widget struct {
+			Name string `json:"name"`
+			Size int    `json:"size"`
+		}
+		return json.Unmarshal([]byte(body), &widget)
+	}
+	for tn, tc := range map[string]struct {
+		err             error
+		wantStatus      int
+		wantExplanation string
+	}{
+		"syntax error": {
+			err:             decode(`{"name": "sprocket",}`),
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: "invalid JSON at offset 21",
+		},
+		"unmarshal type error": {
+			err:             fmt.Errorf("decoding widget: %w", decode(`{"name": "sprocket", "size": "large"}`)),
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: `invalid JSON value for field "size": expected int`,
+		},
+		"unmarshal type error at top level": {
+			err: func() error {
+				var size int
+				return json.Unmarshal([]byte(`"large"`), &size)
+			}(),
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: "invalid JSON value at offset 7: expected int",
+		},
+		"other error": {
+			err:        errForTesting,
+			wantStatus: http.StatusInternalServerError,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got := BecauseJSON(tc.err)
+			if got.Status != tc.wantStatus {
+				t.Errorf("BecauseJSON(): status got: %v, want: %v", got.Status, tc.wantStatus)
+			}
+			if got.Explanation != tc.wantExplanation {
+				t.Errorf("BecauseJSON(): explanation got: %q, want: %q", got.Explanation, tc.wantExplanation)
+			}
+		})
+	}
+}
+
+// apiError is an error which knows its own HTTP status and application code.
+type apiError struct {
+	status int
+	code   string
+}
+
+func (e apiError) Error() string {
+	return fmt.Sprintf("api error %v", e.code)
+}
+
+func (e apiError) StatusCode() int {
+	return e.status
+}
+
+func (e apiError) Code() string {
+	return e.code
+}
+
+func TestBecauseTyped(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		err        error
+		deets      []Detail
+		wantStatus int
+		wantCode   string
+	}{
+		"status and code": {
+			err:        fmt.Errorf("charging card: %w", apiError{http.StatusPaymentRequired, "card_declined"}),
+			wantStatus: http.StatusPaymentRequired,
+			wantCode:   "card_declined",
+		},
+		"status only": {
+			err:        statusCodeError{http.StatusConflict},
+			wantStatus: http.StatusConflict,
+		},
+		"neither": {
+			err:        errForTesting,
+			wantStatus: http.StatusInternalServerError,
+		},
+		"overridden": {
+			err:        apiError{http.StatusPaymentRequired, "card_declined"},
+			deets:      []Detail{WithCode("insufficient_funds")},
+			wantStatus: http.StatusPaymentRequired,
+			wantCode:   "insufficient_funds",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got := BecauseTyped(tc.err, tc.deets...)
+			if got.Status != tc.wantStatus {
+				t.Errorf("BecauseTyped(): status got: %v, want: %v", got.Status, tc.wantStatus)
+			}
+			if got.Code != tc.wantCode {
+				t.Errorf("BecauseTyped(): code got: %q, want: %q", got.Code, tc.wantCode)
+			}
+		})
+	}
+}
+
+// httpStatusError carries its HTTP status as HTTPStatus.
+type httpStatusError struct {
+	status int
+}
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("http status %d", e.status)
+}
+
+func (e httpStatusError) HTTPStatus() int {
+	return e.status
+}
+
+// intCodeError carries its HTTP status as Code.
+type intCodeError struct {
+	code int
+}
+
+func (e intCodeError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+func (e intCodeError) Code() int {
+	return e.code
+}
+
+func TestBecauseHTTP(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		err        error
+		deets      []Detail
+		wantStatus int
+	}{
+		"HTTPStatus": {
+			err:        fmt.Errorf("fetching widget: %w", httpStatusError{http.StatusNotFound}),
+			wantStatus: http.StatusNotFound,
+		},
+		"StatusCode": {
+			err:        statusCodeError{http.StatusConflict},
+			wantStatus: http.StatusConflict,
+		},
+		"Code": {
+			err:        intCodeError{http.StatusTooManyRequests},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		"Code out of range": {
+			err:        intCodeError{40401},
+			wantStatus: http.StatusInternalServerError,
+		},
+		"string Code ignored": {
+			err:        apiError{http.StatusPaymentRequired, "card_declined"},
+			wantStatus: http.StatusPaymentRequired,
+		},
+		"none": {
+			err:        errForTesting,
+			wantStatus: http.StatusInternalServerError,
+		},
+		"overridden": {
+			err:        httpStatusError{http.StatusNotFound},
+			deets:      []Detail{WithStatus(http.StatusGone)},
+			wantStatus: http.StatusGone,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := BecauseHTTP(tc.err, tc.deets...).Status; got != tc.wantStatus {
+				t.Errorf("BecauseHTTP(): status got: %v, want: %v", got, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestBecauseAll(t *testing.T) {
+	errFirst := errors.New("widget 1 not found")
+	errSecond := fmt.Errorf("saving widget 2: %w", errors.New("disk full"))
+	for tn, tc := range map[string]struct {
+		errs        []error
+		deets       []Detail
+		wantStatus  int
+		wantMessage string
+	}{
+		"default status": {
+			errs:        []error{errFirst, nil, errSecond},
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: "widget 1 not found\nsaving widget 2: disk full",
+		},
+		"overridden": {
+			errs:        []error{errFirst, errSecond},
+			deets:       []Detail{WithStatus(http.StatusMultiStatus)},
+			wantStatus:  http.StatusMultiStatus,
+			wantMessage: "widget 1 not found\nsaving widget 2: disk full",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got := BecauseAll(tc.errs, tc.deets...)
+			if got.Status != tc.wantStatus {
+				t.Errorf("BecauseAll(): status got: %v, want: %v", got.Status, tc.wantStatus)
+			}
+			if got.Error() != tc.wantMessage {
+				t.Errorf("BecauseAll(): message got: %q, want: %q", got.Error(), tc.wantMessage)
+			}
+			var subs []string
+			for _, sub := range got.SubProblems {
+				subs = append(subs, sub.Error())
+			}
+			if diff := cmp.Diff([]string{errFirst.Error(), errSecond.Error()}, subs); diff != "" {
+				t.Errorf("BecauseAll(): sub-problem messages mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package httpanic
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RecordedReason is a Reason recovered by GracefullyRecord, with when and
+// where it was recovered.
+type RecordedReason struct {
+	// Time at which the Reason was recovered.
+	Time time.Time
+
+	// Path of the request being served when the Reason was recovered.
+	Path string
+
+	// Reason which was recovered.
+	Reason Reason
+}
+
+// MarshalJSON implements custom JSON marshaling for RecordedReason. Unlike the
+// client-facing representation of Reason, the Reason is represented as by
+// Describe, with its error message even if it is withheld from clients, along
+// with its Source and Cause.
+func (rr RecordedReason) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Time   time.Time              `json:"time"`
+		Path   string                 `json:"path"`
+		Reason map[string]interface{} `json:"reason"`
+	}{rr.Time, rr.Path, rr.Reason.Describe()})
+}
+
+// Recorder keeps the most recent Reasons recovered by GracefullyRecord in
+// memory, for a debugging endpoint, for example. Once it is full, each Reason
+// recorded evicts the oldest. It is safe for concurrent use.
+//
+// A Recorder is also an http.Handler, which serves the Recent Reasons as a JSON
+// array. Since the Reasons include their error messages and Sources whether or
+// not they are meant for clients, it should not be exposed publicly.
+type Recorder struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries []RecordedReason
+	next    int
+	full    bool
+}
+
+// NewRecorder returns a Recorder which keeps the size most recent Reasons. It
+// panics if size is not positive.
+func NewRecorder(size int) *Recorder {
+	if size < 1 {
+		panic("httpanic: non-positive size for NewRecorder")
+	}
+	return &Recorder{now: time.Now, entries: make([]RecordedReason, size)}
+}
+
+// record adds the Reason recovered while serving r, evicting the oldest if the
+// Recorder is full. The Reason is cloned, so that it shares nothing with the
+// one being rendered.
+func (rec *Recorder) record(r *http.Request, reason Reason) {
+	entry := RecordedReason{
+		Time:   rec.now(),
+		Path:   r.URL.Path,
+		Reason: reason.Clone(),
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.entries[rec.next] = entry
+	rec.next = (rec.next + 1) % len(rec.entries)
+	if rec.next == 0 {
+		rec.full = true
+	}
+}
+
+// Recent returns the Reasons kept by the Recorder, oldest first.
+func (rec *Recorder) Recent() []RecordedReason {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if !rec.full {
+		return append([]RecordedReason(nil), rec.entries[:rec.next]...)
+	}
+	recent := make([]RecordedReason, 0, len(rec.entries))
+	recent = append(recent, rec.entries[rec.next:]...)
+	return append(recent, rec.entries[:rec.next]...)
+}
+
+// ServeHTTP serves the Recent Reasons as a JSON array, oldest first.
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	recent := rec.Recent()
+	if recent == nil {
+		recent = []RecordedReason{}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respond(w, http.StatusOK, "application/json; charset=utf-8", func(b *bytes.Buffer) error {
+		return json.NewEncoder(b).Encode(recent)
+	})
+}
+
+// GracefullyRecord behaves like GracefullyRender, and additionally records
+// each recovered Reason in rec, with the time and the path of the request,
+// just before it is rendered. When nested within other middleware from this
+// package, as when every route is wrapped by Gracefully, the outer middleware
+// recovers the Reasons, and renders them with render as described for New, and
+// they are still recorded.
+func GracefullyRecord(next http.Handler, render Renderer, rec *Recorder) http.Handler {
+	return GracefullyRender(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		onRecover(r, rec.record)
+		next.ServeHTTP(w, r)
+	}), render)
+}
@@ -0,0 +1,206 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecorderRecent(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	for tn, tc := range map[string]struct {
+		size     int
+		recorded int
+		want     []string
+	}{
+		"empty": {
+			size: 3,
+		},
+		"partly full": {
+			size:     3,
+			recorded: 2,
+			want:     []string{"/widgets/0", "/widgets/1"},
+		},
+		"exactly full": {
+			size:     3,
+			recorded: 3,
+			want:     []string{"/widgets/0", "/widgets/1", "/widgets/2"},
+		},
+		"oldest evicted": {
+			size:     3,
+			recorded: 5,
+			want:     []string{"/widgets/2", "/widgets/3", "/widgets/4"},
+		},
+		"size one": {
+			size:     1,
+			recorded: 4,
+			want:     []string{"/widgets/3"},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			clock := &fakeClock{t: start}
+			rec := NewRecorder(tc.size)
+			rec.now = clock.now
+			for i := 0; i < tc.recorded; i++ {
+				rec.record(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/widgets/%d", i), nil), Status(http.StatusNotFound))
+				clock.advance(time.Second)
+			}
+			var got []string
+			for i, entry := range rec.Recent() {
+				got = append(got, entry.Path)
+				if want := start.Add(time.Duration(tc.recorded-len(tc.want)+i) * time.Second); !entry.Time.Equal(want) {
+					t.Errorf("Recent(): %v time got: %v, want: %v", entry.Path, entry.Time, want)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Recent(): mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestNewRecorderPanicsOnBadSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewRecorder(0): did not panic")
+		}
+	}()
+	NewRecorder(0)
+}
+
+func TestGracefullyRecord(t *testing.T) {
+	rec := NewRecorder(2)
+	rec.now = (&fakeClock{t: time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)}).now
+	handler := GracefullyRecord(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			return
+		}
+		panic(Because(errors.New("widget not found"), WithStatus(http.StatusNotFound), WithHeader("X-Widget", "1")))
+	}), StatusOnly, rec)
+
+	for _, path := range []string{"/widgets/1", "/ok"} {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		if path == "/widgets/1" && resp.Code != http.StatusNotFound {
+			t.Errorf("GracefullyRecord(): status got: %v, want: %v", resp.Code, http.StatusNotFound)
+		}
+	}
+
+	recent := rec.Recent()
+	if len(recent) != 1 {
+		t.Fatalf("GracefullyRecord(): recorded got: %v, want: 1", len(recent))
+	}
+	if got := recent[0].Path; got != "/widgets/1" {
+		t.Errorf("GracefullyRecord(): path got: %q, want: %q", got, "/widgets/1")
+	}
+	if got := recent[0].Reason.Status; got != http.StatusNotFound {
+		t.Errorf("GracefullyRecord(): status got: %v, want: %v", got, http.StatusNotFound)
+	}
+
+	resp := httptest.NewRecorder()
+	rec.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/debug/httpanic", nil))
+	if got := resp.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("Recorder.ServeHTTP(): Content-Type got: %q", got)
+	}
+	var got []struct {
+		Time   time.Time              `json:"time"`
+		Path   string                 `json:"path"`
+		Reason map[string]interface{} `json:"reason"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Recorder.ServeHTTP(): body does not unmarshal: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Recorder.ServeHTTP(): got %v entries, want: 1", len(got))
+	}
+	if want := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC); !got[0].Time.Equal(want) || got[0].Path != "/widgets/1" {
+		t.Errorf("Recorder.ServeHTTP(): entry got: %v %v, want: %v %v", got[0].Time, got[0].Path, want, "/widgets/1")
+	}
+	if got, want := got[0].Reason["error"], "widget not found"; got != want {
+		t.Errorf("Recorder.ServeHTTP(): error got: %v, want: %v", got, want)
+	}
+	if got, want := got[0].Reason["status"], float64(http.StatusNotFound); got != want {
+		t.Errorf("Recorder.ServeHTTP(): status got: %v, want: %v", got, want)
+	}
+}
+
+func TestRecorderRuntimePanic(t *testing.T) {
+	rec := NewRecorder(2)
+	handler := GracefullyRecord(http.HandlerFunc(panicNilMap), AsJSON, rec)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/widgets/1", nil))
+	if got, want := resp.Body.String(), `{"error":"Internal Server Error","status":500}`+"\n"; got != want {
+		t.Errorf("GracefullyRecord(): client body got: %v, want: %v", got, want)
+	}
+
+	resp = httptest.NewRecorder()
+	rec.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/debug/httpanic", nil))
+	var got []struct {
+		Reason map[string]interface{} `json:"reason"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Recorder.ServeHTTP(): body does not unmarshal: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Recorder.ServeHTTP(): got %v entries, want: 1", len(got))
+	}
+	if msg, _ := got[0].Reason["error"].(string); !strings.Contains(msg, "nil map") {
+		t.Errorf("Recorder.ServeHTTP(): error got: %q, want the runtime error", msg)
+	}
+	if source, _ := got[0].Reason["source"].(string); !strings.HasSuffix(source, "httpanic.panicNilMap") {
+		t.Errorf("Recorder.ServeHTTP(): source got: %q, want suffix: %q", source, "httpanic.panicNilMap")
+	}
+}
+
+func TestGracefullyRecordNested(t *testing.T) {
+	rec := NewRecorder(2)
+	handler := Gracefully(GracefullyRecord(http.HandlerFunc(panicTeapot), StatusOnly, rec))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/widgets/1", nil))
+	if resp.Code != http.StatusTeapot {
+		t.Errorf("GracefullyRecord(): status got: %v, want: %v", resp.Code, http.StatusTeapot)
+	}
+	recent := rec.Recent()
+	if len(recent) != 1 {
+		t.Fatalf("GracefullyRecord(): recorded got: %v, want: 1", len(recent))
+	}
+	if got := recent[0].Reason.Status; got != http.StatusTeapot {
+		t.Errorf("GracefullyRecord(): status got: %v, want: %v", got, http.StatusTeapot)
+	}
+}
+
+func TestRecorderServeHTTPEmpty(t *testing.T) {
+	resp := httptest.NewRecorder()
+	NewRecorder(2).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/debug/httpanic", nil))
+	if got, want := resp.Body.String(), "[]\n"; got != want {
+		t.Errorf("Recorder.ServeHTTP(): got: %q, want: %q", got, want)
+	}
+}
+
+func TestRecorderConcurrent(t *testing.T) {
+	rec := NewRecorder(8)
+	handler := GracefullyRecord(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Status(http.StatusTeapot))
+	}), StatusOnly, rec)
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			rec.Recent()
+		}()
+	}
+	wg.Wait()
+	if got := len(rec.Recent()); got != 8 {
+		t.Errorf("Recent(): got %v entries, want: 8", got)
+	}
+}
@@ -0,0 +1,120 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxBodyBytes is the largest request body DecodeJSON reads, unless
+// told otherwise with MaxBodyBytes.
+const DefaultMaxBodyBytes = 1 << 20
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// DecodeOption configures DecodeJSON.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	disallowUnknownFields bool
+	maxBodyBytes          int64
+}
+
+// DisallowUnknownFields causes DecodeJSON to reject bodies containing object
+// keys which do not match any field of the destination.
+func DisallowUnknownFields() DecodeOption {
+	return func(o *decodeOptions) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// MaxBodyBytes sets the largest request body DecodeJSON reads. If n is not
+// positive, the size of the body is not limited.
+func MaxBodyBytes(n int64) DecodeOption {
+	return func(o *decodeOptions) {
+		o.maxBodyBytes = n
+	}
+}
+
+// DecodeJSON decodes the JSON body of r into v. If the body is not valid JSON,
+// or does not fit into v, DecodeJSON panics with a Reason having status 400 Bad
+// Request. If the body is larger than allowed, DecodeJSON panics with a Reason
+// having status 413 Request Entity Too Large.
+func DecodeJSON(r *http.Request, v interface{}, opts ...DecodeOption) {
+	o := decodeOptions{maxBodyBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var body io.Reader = http.NoBody
+	if r.Body != nil {
+		body = r.Body
+		if o.maxBodyBytes > 0 {
+			body = &limitedReader{r: r.Body, n: o.maxBodyBytes}
+		}
+	}
+	dec := json.NewDecoder(body)
+	if o.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, errBodyTooLarge) {
+			panic(Because(err,
+				WithStatus(http.StatusRequestEntityTooLarge),
+				WithExplanation("request body too large")))
+		}
+		panic(Because(err,
+			WithStatus(http.StatusBadRequest),
+			WithExplanation("invalid JSON body")))
+	}
+}
+
+// RequireHeader returns the value of the named header of r. If the header is
+// missing or empty, RequireHeader panics with a Reason having status 400 Bad
+// Request.
+func RequireHeader(r *http.Request, name string) string {
+	v := r.Header.Get(name)
+	if v == "" {
+		panic(Because(fmt.Errorf("missing required header %q", name),
+			WithStatus(http.StatusBadRequest),
+			WithExplanation(fmt.Sprintf("missing required header %v", http.CanonicalHeaderKey(name)))))
+	}
+	return v
+}
+
+// RequireQuery returns the value of the query parameter key of r. If the
+// parameter is missing or empty, RequireQuery panics with a Reason having
+// status 400 Bad Request.
+func RequireQuery(r *http.Request, key string) string {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		panic(Because(fmt.Errorf("missing required query parameter %q", key),
+			WithStatus(http.StatusBadRequest),
+			WithExplanation(fmt.Sprintf("missing required query parameter %v", key))))
+	}
+	return v
+}
+
+// limitedReader reads from r, failing with errBodyTooLarge once more than n
+// bytes have been read. Unlike io.LimitedReader, exceeding the limit is an
+// error rather than the end of the body.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,202 @@
+package httpanic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recoverReason calls f, returning the Reason it panics with, if any.
+func recoverReason(t *testing.T, f func()) (reason Reason, panicked bool) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			var ok bool
+			if reason, ok = r.(Reason); !ok {
+				t.Fatalf("unexpected panic with non-Reason: %v", r)
+			}
+			panicked = true
+		}
+	}()
+	f()
+	return
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type widget struct {
+		Name string `json:"name"`
+	}
+	for tn, tc := range map[string]struct {
+		body            string
+		opts            []DecodeOption
+		want            widget
+		wantStatus      int
+		wantExplanation string
+	}{
+		"valid": {
+			body: `{"name":"sprocket"}`,
+			want: widget{Name: "sprocket"},
+		},
+		"unknown fields allowed by default": {
+			body: `{"name":"sprocket","color":"blue"}`,
+			want: widget{Name: "sprocket"},
+		},
+		"malformed": {
+			body:            `{"name":`,
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: "invalid JSON body",
+		},
+		"wrong type": {
+			body:            `{"name":42}`,
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: "invalid JSON body",
+		},
+		"empty": {
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: "invalid JSON body",
+		},
+		"unknown fields disallowed": {
+			body:            `{"name":"sprocket","color":"blue"}`,
+			opts:            []DecodeOption{DisallowUnknownFields()},
+			wantStatus:      http.StatusBadRequest,
+			wantExplanation: "invalid JSON body",
+		},
+		"exactly at size limit": {
+			body: `{"name":"sprocket"}`,
+			opts: []DecodeOption{MaxBodyBytes(19)},
+			want: widget{Name: "sprocket"},
+		},
+		"unlimited": {
+			body: `{"name":"sprocket"}`,
+			opts: []DecodeOption{MaxBodyBytes(0)},
+			want: widget{Name: "sprocket"},
+		},
+		"too large": {
+			body:            `{"name":"sprocket"}`,
+			opts:            []DecodeOption{MaxBodyBytes(10)},
+			wantStatus:      http.StatusRequestEntityTooLarge,
+			wantExplanation: "request body too large",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			var got widget
+			reason, panicked := recoverReason(t, func() {
+				DecodeJSON(req, &got, tc.opts...)
+			})
+			if tc.wantStatus == 0 {
+				if panicked {
+					t.Fatalf("DecodeJSON(): unexpected panic: %v", reason)
+				}
+				if got != tc.want {
+					t.Errorf("DecodeJSON(): got: %+v, want: %+v", got, tc.want)
+				}
+				return
+			}
+			if !panicked {
+				t.Fatal("DecodeJSON(): want panic, got none")
+			}
+			if reason.Status != tc.wantStatus {
+				t.Errorf("DecodeJSON(): status got: %v, want: %v", reason.Status, tc.wantStatus)
+			}
+			if reason.Explanation != tc.wantExplanation {
+				t.Errorf("DecodeJSON(): explanation got: %q, want: %q", reason.Explanation, tc.wantExplanation)
+			}
+		})
+	}
+}
+
+func TestRequireHeader(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		header          http.Header
+		want            string
+		wantExplanation string
+	}{
+		"present": {
+			header: http.Header{"X-Api-Key": {"hunter2"}},
+			want:   "hunter2",
+		},
+		"missing": {
+			wantExplanation: "missing required header X-Api-Key",
+		},
+		"empty": {
+			header:          http.Header{"X-Api-Key": {""}},
+			wantExplanation: "missing required header X-Api-Key",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header = tc.header
+			var got string
+			reason, panicked := recoverReason(t, func() {
+				got = RequireHeader(req, "x-api-key")
+			})
+			if tc.wantExplanation == "" {
+				if panicked {
+					t.Fatalf("RequireHeader(): unexpected panic: %v", reason)
+				}
+				if got != tc.want {
+					t.Errorf("RequireHeader(): got: %q, want: %q", got, tc.want)
+				}
+				return
+			}
+			if !panicked {
+				t.Fatal("RequireHeader(): want panic, got none")
+			}
+			if reason.Status != http.StatusBadRequest {
+				t.Errorf("RequireHeader(): status got: %v, want: %v", reason.Status, http.StatusBadRequest)
+			}
+			if reason.Explanation != tc.wantExplanation {
+				t.Errorf("RequireHeader(): explanation got: %q, want: %q", reason.Explanation, tc.wantExplanation)
+			}
+		})
+	}
+}
+
+func TestRequireQuery(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		target          string
+		want            string
+		wantExplanation string
+	}{
+		"present": {
+			target: "/widgets?color=blue",
+			want:   "blue",
+		},
+		"missing": {
+			target:          "/widgets",
+			wantExplanation: "missing required query parameter color",
+		},
+		"empty": {
+			target:          "/widgets?color=",
+			wantExplanation: "missing required query parameter color",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			var got string
+			reason, panicked := recoverReason(t, func() {
+				got = RequireQuery(req, "color")
+			})
+			if tc.wantExplanation == "" {
+				if panicked {
+					t.Fatalf("RequireQuery(): unexpected panic: %v", reason)
+				}
+				if got != tc.want {
+					t.Errorf("RequireQuery(): got: %q, want: %q", got, tc.want)
+				}
+				return
+			}
+			if !panicked {
+				t.Fatal("RequireQuery(): want panic, got none")
+			}
+			if reason.Status != http.StatusBadRequest {
+				t.Errorf("RequireQuery(): status got: %v, want: %v", reason.Status, http.StatusBadRequest)
+			}
+			if reason.Explanation != tc.wantExplanation {
+				t.Errorf("RequireQuery(): explanation got: %q, want: %q", reason.Explanation, tc.wantExplanation)
+			}
+		})
+	}
+}
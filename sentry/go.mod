@@ -0,0 +1,17 @@
+module github.com/cfunkhouser/httpanic/sentry
+
+go 1.18
+
+require (
+	github.com/cfunkhouser/httpanic v0.0.0-20261014102555-96928b21c495
+	github.com/getsentry/sentry-go v0.29.1
+)
+
+require (
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+// Build against the root module in this repository during development. Users
+// of this module ignore the replacement, and get the version required above.
+replace github.com/cfunkhouser/httpanic => ../
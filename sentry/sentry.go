@@ -0,0 +1,57 @@
+// Package sentry reports Reasons for panicking to Sentry. It is kept separate
+// so that the httpanic package itself carries no dependency on the Sentry SDK.
+package sentry
+
+import (
+	"net/http"
+	"strconv"
+
+	sentrygo "github.com/getsentry/sentry-go"
+
+	"github.com/cfunkhouser/httpanic"
+)
+
+// Option configures the reporting of Reasons to Sentry.
+type Option func(*options)
+
+type options struct {
+	includeClientErrors bool
+}
+
+// IncludeClientErrors causes Reasons with a 4xx status to be reported, as well
+// as those with a 5xx status.
+func IncludeClientErrors() Option {
+	return func(o *options) {
+		o.includeClientErrors = true
+	}
+}
+
+// Sentry returns a RequestRenderer which reports each Reason with a 5xx status
+// to Sentry using hub, and then renders it with render. The report captures
+// the Reason as an exception, tagged with its status, along with the request
+// being served when the panic happened. Reasons with any other status are
+// rendered without being reported, unless IncludeClientErrors is given.
+func Sentry(hub *sentrygo.Hub, render httpanic.Renderer, opts ...Option) httpanic.RequestRenderer {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(w http.ResponseWriter, r *http.Request, reason httpanic.Reason) {
+		if o.reports(reason.Status) {
+			hub.WithScope(func(scope *sentrygo.Scope) {
+				scope.SetTag("status", strconv.Itoa(reason.Status))
+				scope.SetRequest(r)
+				hub.CaptureException(reason)
+			})
+		}
+		render(w, reason)
+	}
+}
+
+// reports whether a Reason with status should be reported.
+func (o options) reports(status int) bool {
+	if status >= http.StatusInternalServerError {
+		return true
+	}
+	return o.includeClientErrors && status >= http.StatusBadRequest
+}
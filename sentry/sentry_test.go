@@ -0,0 +1,90 @@
+package sentry
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+
+	"github.com/cfunkhouser/httpanic"
+)
+
+// fakeTransport is a sentry-go Transport which keeps the events it is given,
+// rather than sending them anywhere.
+type fakeTransport struct {
+	events []*sentrygo.Event
+}
+
+func (t *fakeTransport) Flush(time.Duration) bool { return true }
+
+func (t *fakeTransport) Configure(sentrygo.ClientOptions) {}
+
+func (t *fakeTransport) SendEvent(event *sentrygo.Event) {
+	t.events = append(t.events, event)
+}
+
+func TestSentry(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		status     int
+		opts       []Option
+		wantReport bool
+	}{
+		"server error": {
+			status:     http.StatusBadGateway,
+			wantReport: true,
+		},
+		"client error": {
+			status: http.StatusTeapot,
+		},
+		"client error included": {
+			status:     http.StatusTeapot,
+			opts:       []Option{IncludeClientErrors()},
+			wantReport: true,
+		},
+		"success status included": {
+			status: http.StatusNoContent,
+			opts:   []Option{IncludeClientErrors()},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			transport := &fakeTransport{}
+			client, err := sentrygo.NewClient(sentrygo.ClientOptions{Transport: transport})
+			if err != nil {
+				t.Fatalf("sentry.NewClient(): unexpected error: %v", err)
+			}
+			hub := sentrygo.NewHub(client, sentrygo.NewScope())
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/widgets/1", nil)
+			reason := httpanic.Because(errors.New("rut-ro raggy"), httpanic.WithStatus(tc.status))
+			Sentry(hub, httpanic.StatusOnly, tc.opts...)(rec, req, reason)
+
+			if rec.Code != tc.status {
+				t.Errorf("Sentry(): status got: %v, want: %v", rec.Code, tc.status)
+			}
+			if !tc.wantReport {
+				if len(transport.events) != 0 {
+					t.Errorf("Sentry(): got %d events, want none", len(transport.events))
+				}
+				return
+			}
+			if len(transport.events) != 1 {
+				t.Fatalf("Sentry(): got %d events, want 1", len(transport.events))
+			}
+			event := transport.events[0]
+			if got, want := event.Tags["status"], strconv.Itoa(tc.status); got != want {
+				t.Errorf("Sentry(): status tag got: %q, want: %q", got, want)
+			}
+			if len(event.Exception) == 0 || event.Exception[len(event.Exception)-1].Value != "rut-ro raggy" {
+				t.Errorf("Sentry(): exception got: %+v, want the Reason error", event.Exception)
+			}
+			if event.Request == nil || event.Request.URL != "http://example.com/widgets/1" {
+				t.Errorf("Sentry(): request got: %+v, want the request served", event.Request)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+//go:build go1.21
+// +build go1.21
+
+package httpanic
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// LogJSON returns a RequestRenderer which logs each Reason to logger, along
+// with the request being served when the panic happened, and then renders it
+// with render. Despite the name, the format of the log is up to the
+// slog.Handler of logger; the name reflects that it is most useful with
+// slog.JSONHandler. Reasons with a 5xx status are logged at slog.LevelError,
+// and all others at slog.LevelWarn. Reasons already marked as logged using
+// MarkLogged are not logged again, and those which are logged are marked before
+// being rendered.
+func LogJSON(logger *slog.Logger, render Renderer) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		if reason.WasLogged() {
+			render(w, reason)
+			return
+		}
+		level := slog.LevelWarn
+		if reason.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(r.Context(), level, "http: panic serving request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+			slog.Int("status", reason.Status),
+			slog.String("error", reason.Error()))
+		render(w, reason.MarkLogged())
+	}
+}
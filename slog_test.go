@@ -0,0 +1,106 @@
+//go:build go1.21
+// +build go1.21
+
+package httpanic
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingHandler is a slog.Handler which keeps the records it handles.
+type recordingHandler struct {
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+func TestLogJSON(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		status    int
+		wantLevel slog.Level
+	}{
+		"client error": {
+			status:    http.StatusTeapot,
+			wantLevel: slog.LevelWarn,
+		},
+		"server error": {
+			status:    http.StatusBadGateway,
+			wantLevel: slog.LevelError,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			h := &recordingHandler{}
+			req := httptest.NewRequest(http.MethodPost, "/widgets?verbose=true", nil)
+			req.Header.Set("User-Agent", "httpanic-test/1.0")
+			rec := httptest.NewRecorder()
+			LogJSON(slog.New(h), StatusOnly)(rec, req, Because(errForTesting, WithStatus(tc.status)))
+
+			if rec.Code != tc.status {
+				t.Errorf("LogJSON(): status got: %v, want: %v", rec.Code, tc.status)
+			}
+			if len(h.records) != 1 {
+				t.Fatalf("LogJSON(): got %d records, want 1", len(h.records))
+			}
+			r := h.records[0]
+			if r.Level != tc.wantLevel {
+				t.Errorf("LogJSON(): level got: %v, want: %v", r.Level, tc.wantLevel)
+			}
+			got := map[string]interface{}{}
+			r.Attrs(func(a slog.Attr) bool {
+				got[a.Key] = a.Value.Any()
+				return true
+			})
+			want := map[string]interface{}{
+				"method":      http.MethodPost,
+				"path":        "/widgets",
+				"remote_addr": "192.0.2.1:1234",
+				"user_agent":  "httpanic-test/1.0",
+				"status":      int64(tc.status),
+				"error":       "rut-ro raggy",
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("LogJSON(): attributes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestLogJSONSkipsLogged(t *testing.T) {
+	var buf bytes.Buffer
+	h := &recordingHandler{}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler := GracefullyRenderErrorLog(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusBadGateway)))
+	}), func(w http.ResponseWriter, reason Reason) {
+		LogJSON(slog.New(h), StatusOnly)(w, req, reason)
+	}, log.New(&buf, "", 0))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("LogJSON(): status got: %v, want: %v", rec.Code, http.StatusBadGateway)
+	}
+	if buf.Len() == 0 {
+		t.Errorf("GracefullyRenderErrorLog(): Reason not logged")
+	}
+	if len(h.records) != 0 {
+		t.Errorf("LogJSON(): got %d records for a logged Reason, want 0", len(h.records))
+	}
+}
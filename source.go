@@ -0,0 +1,52 @@
+package httpanic
+
+import (
+	"runtime"
+	"strings"
+)
+
+// helpers are the functions of this package which panic on behalf of their
+// callers, so that panicSource names the caller instead.
+var helpers = map[string]bool{
+	"github.com/cfunkhouser/httpanic.DecodeJSON":    true,
+	"github.com/cfunkhouser/httpanic.RequireHeader": true,
+	"github.com/cfunkhouser/httpanic.RequireQuery":  true,
+}
+
+// panicSource returns the name of the function which panicked, for a call
+// deferred by that function or its callers while the panic is in progress. It
+// is found on the stack below the outermost call to panic, which is where the
+// panic began even if a deferred function has since panicked again, skipping
+// any frames of the runtime itself, like those of a nil map assignment, and of
+// this package's helpers, like RequireHeader.
+func panicSource() string {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	frames := runtime.CallersFrames(pcs)
+	var source string
+	for inPanic := false; ; {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			inPanic, source = true, ""
+		case inPanic && source == "" && !strings.HasPrefix(frame.Function, "runtime.") && !helpers[frame.Function]:
+			source = frame.Function
+		}
+		if !more {
+			return source
+		}
+	}
+}
+
+// callerName returns the name of the function which called the function
+// calling callerName, or the empty string if it cannot be determined.
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package httpanic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// panicNilMap is a named handler function, which assigns to a nil map.
+func panicNilMap(http.ResponseWriter, *http.Request) {
+	var widgets map[string]int
+	widgets["sprocket"]++
+}
+
+// panicFromHelper is a named handler function, which panics in a helper.
+func panicFromHelper(http.ResponseWriter, *http.Request) {
+	mustFindWidget("sprocket")
+}
+
+func mustFindWidget(name string) {
+	panic(Because(errForTesting, WithStatus(http.StatusNotFound)))
+}
+
+// requireWidgetHeader is a named handler function, which panics in
+// RequireHeader when the request has no X-Widget header.
+func requireWidgetHeader(_ http.ResponseWriter, r *http.Request) {
+	RequireHeader(r, "X-Widget")
+}
+
+// widgetRoutes is a named function, which routes to a Stub.
+func widgetRoutes() http.Handler {
+	return Stub()
+}
+
+func TestSource(t *testing.T) {
+	explicit := Because(errForTesting)
+	explicit.Source = "widgets.Get"
+	for tn, tc := range map[string]struct {
+		handler http.Handler
+		want    string
+	}{
+		"named handler": {
+			handler: http.HandlerFunc(panicTeapot),
+			want:    "httpanic.panicTeapot",
+		},
+		"runtime error": {
+			handler: http.HandlerFunc(panicNilMap),
+			want:    "httpanic.panicNilMap",
+		},
+		"helper": {
+			handler: http.HandlerFunc(panicFromHelper),
+			want:    "httpanic.mustFindWidget",
+		},
+		"package helper": {
+			handler: http.HandlerFunc(requireWidgetHeader),
+			want:    "httpanic.requireWidgetHeader",
+		},
+		"stub": {
+			handler: widgetRoutes(),
+			want:    "httpanic.widgetRoutes",
+		},
+		"panicked again": {
+			handler: WithDefaults(WithExplanation("Widgets are broken."))(http.HandlerFunc(panicTeapot)),
+			want:    "httpanic.panicTeapot",
+		},
+		"explicit": {
+			handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(explicit)
+			}),
+			want: "widgets.Get",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var got Reason
+			GracefullyRender(tc.handler, func(w http.ResponseWriter, reason Reason) {
+				got = reason
+			}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			if !strings.HasSuffix(got.Source, tc.want) {
+				t.Errorf("Source got: %q, want suffix: %q", got.Source, tc.want)
+			}
+		})
+	}
+}
+
+func TestSourceDebugOnly(t *testing.T) {
+	reason := Because(errForTesting, WithStatus(http.StatusNotFound))
+	reason.Source = "widgets.Get"
+	for tn, tc := range map[string]struct {
+		debug bool
+		want  string
+	}{
+		"debug": {
+			debug: true,
+			want:  `{"error":"rut-ro raggy","status":404,"source":"widgets.Get"}` + "\n",
+		},
+		"not debug": {
+			want: `{"error":"rut-ro raggy","status":404}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			withDebug(t, tc.debug, func() {
+				for rn, render := range map[string]Renderer{
+					"AsJSON":     AsJSON,
+					"AsJSONFast": AsJSONFast,
+				} {
+					rec := httptest.NewRecorder()
+					render(rec, reason)
+					if got := rec.Body.String(); got != tc.want {
+						t.Errorf("%v:\n got:%v\nwant:%v\n", rn, got, tc.want)
+					}
+				}
+			})
+		})
+	}
+}
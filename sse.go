@@ -0,0 +1,39 @@
+package httpanic
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// AsSSEError renders a Reason for panicking from a server-sent events
+// endpoint. Once the response has started as a text/event-stream, its status
+// can no longer be changed, so the Reason is instead sent as a final event
+// named "error", with the Reason as it would be rendered by AsJSON for data.
+// Otherwise, the Reason is rendered by AsJSON. If any errors are encountered
+// during render, this function will panic.
+func AsSSEError(w http.ResponseWriter, reason Reason) {
+	if !started(w) || !eventStream(w.Header()) {
+		AsJSON(w, reason)
+		return
+	}
+	data, err := json.Marshal(reason.jsonWith(errorString))
+	if err != nil {
+		panic(err)
+	}
+	frame := make([]byte, 0, len(data)+20)
+	frame = append(frame, "event: error\ndata: "...)
+	frame = append(frame, data...)
+	frame = append(frame, "\n\n"...)
+	w.Write(frame)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// eventStream reports whether h declares the response to be a
+// text/event-stream.
+func eventStream(h http.Header) bool {
+	mt, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && mt == "text/event-stream"
+}
@@ -0,0 +1,61 @@
+package httpanic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAsSSEError(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		handler         http.HandlerFunc
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		"event stream": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte("data: widget 1\n\n"))
+				w.(http.Flusher).Flush()
+				panic(Because(errForTesting, WithStatus(http.StatusBadGateway), WithExplanation("Lost the widgets.")))
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/event-stream",
+			wantBody:        "data: widget 1\n\nevent: error\ndata: {\"error\":\"rut-ro raggy\",\"explanation\":\"Lost the widgets.\",\"status\":502}\n\n",
+		},
+		"not started": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				panic(Because(errForTesting, WithStatus(http.StatusBadGateway)))
+			},
+			wantStatus:      http.StatusBadGateway,
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"error":"rut-ro raggy","status":502}` + "\n",
+		},
+		"not an event stream": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("widget 1\n"))
+				panic(Because(errForTesting, WithStatus(http.StatusBadGateway)))
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain",
+			wantBody:        "widget 1\n" + `{"error":"rut-ro raggy","status":502}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			GracefullyRender(tc.handler, AsSSEError).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("AsSSEError(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Result().Header.Get("Content-Type"); got != tc.wantContentType {
+				t.Errorf("AsSSEError(): Content-Type got: %q, want: %q", got, tc.wantContentType)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("AsSSEError():\n got:%q\nwant:%q\n", got, tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package httpanic
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+var (
+	// statusTextsMu guards statusTexts.
+	statusTextsMu sync.RWMutex
+
+	// statusTexts registered using RegisterStatusText, by status.
+	statusTexts = map[int]string{}
+)
+
+// RegisterStatusText sets the text used by the built-in Renderers for code, in
+// place of the standard text from http.StatusText. It is most useful for
+// non-standard statuses, which have no standard text.
+func RegisterStatusText(code int, text string) {
+	statusTextsMu.Lock()
+	defer statusTextsMu.Unlock()
+	statusTexts[code] = text
+}
+
+// statusText returns the text registered for code, or the standard text for it
+// if none is registered. For codes with neither, it returns a generic text
+// naming the code, so that it is never blank.
+func statusText(code int) string {
+	statusTextsMu.RLock()
+	text, ok := statusTexts[code]
+	statusTextsMu.RUnlock()
+	if ok {
+		return text
+	}
+	if text := http.StatusText(code); text != "" {
+		return text
+	}
+	return "Status " + strconv.Itoa(code)
+}
+
+// StatusOf returns the HTTP status which this package's middleware would
+// render for a value recovered from a panic, and whether it is a value the
+// middleware would render at all. Errors and strings are classified as they
+// are by the default Reasoner, Because. Since there is no request, any function
+// set using WithStatusFunc is not called. StatusOf is intended for tests which
+// check the status of a panic without serving a request.
+func StatusOf(recovered interface{}) (int, bool) {
+	reason, ok := ReasonFrom(recovered, Because)
+	return reason.Status, ok
+}
@@ -0,0 +1,102 @@
+package httpanic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusText(t *testing.T) {
+	RegisterStatusText(420, "Enhance Your Calm")
+	t.Cleanup(func() {
+		statusTextsMu.Lock()
+		defer statusTextsMu.Unlock()
+		delete(statusTexts, 420)
+	})
+	for tn, tc := range map[string]struct {
+		code int
+		want string
+	}{
+		"registered": {
+			code: 420,
+			want: "Enhance Your Calm",
+		},
+		"standard": {
+			code: http.StatusTeapot,
+			want: "I'm a teapot",
+		},
+		"neither": {
+			code: 499,
+			want: "Status 499",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := statusText(tc.code); got != tc.want {
+				t.Errorf("statusText(): got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+	for tn, tc := range map[string]struct {
+		render Renderer
+		want   string
+	}{
+		"TextStatusRenderer": {
+			render: TextStatusRenderer,
+			want:   "Enhance Your Calm\n",
+		},
+		"AsJSON with empty error": {
+			render: func(w http.ResponseWriter, reason Reason) {
+				AsJSON(w, Because(errors.New(""), WithStatus(reason.Status)))
+			},
+			want: `{"error":"Enhance Your Calm","status":420}` + "\n",
+		},
+		"AsProblemJSON": {
+			render: AsProblemJSON,
+			want:   `{"type":"about:blank","title":"Enhance Your Calm","status":420,"detail":"Enhance Your Calm"}` + "\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.render(rec, Status(420))
+			if got := rec.Body.String(); got != tc.want {
+				t.Errorf("%v:\n got:%v\nwant:%v\n", tn, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		recovered  interface{}
+		wantStatus int
+		wantOK     bool
+	}{
+		"reason": {
+			recovered:  Because(errForTesting, WithStatus(http.StatusNotFound)),
+			wantStatus: http.StatusNotFound,
+			wantOK:     true,
+		},
+		"error": {
+			recovered:  errForTesting,
+			wantStatus: http.StatusInternalServerError,
+			wantOK:     true,
+		},
+		"string": {
+			recovered:  "this is a string",
+			wantStatus: http.StatusInternalServerError,
+			wantOK:     true,
+		},
+		"unknown": {
+			recovered: &weirdPanic{"this would be weird"},
+		},
+		"nil": {},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			gotStatus, gotOK := StatusOf(tc.recovered)
+			if gotStatus != tc.wantStatus || gotOK != tc.wantOK {
+				t.Errorf("StatusOf(): got: %v, %v, want: %v, %v", gotStatus, gotOK, tc.wantStatus, tc.wantOK)
+			}
+		})
+	}
+}
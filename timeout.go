@@ -0,0 +1,61 @@
+package httpanic
+
+import (
+	"net/http"
+	"time"
+)
+
+// GracefullyRenderTimeout behaves like GracefullyRender, except that render is
+// given at most d to render each Reason. If it takes longer, a bare 500
+// Internal Server Error is sent instead, and whatever render goes on to write
+// is discarded. Since render runs on another goroutine, it writes to a buffer
+// rather than to the response, which is sent only once render returns in time.
+// As a result, Renderers which depend on the state of the response, like
+// AsTrailer, behave as though it had not yet started. If render panics, the
+// panic continues on the goroutine serving the request.
+//
+// When nested within other middleware from this package, as when every route
+// is wrapped by Gracefully, the outer middleware recovers each Reason, and
+// renders it with render as described for New, but is given at most d to do
+// so.
+func GracefullyRenderTimeout(next http.Handler, render Renderer, d time.Duration) http.Handler {
+	return GracefullyRender(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		onRender(r, func(render RequestRenderer) RequestRenderer {
+			return limitRender(render, d)
+		})
+		next.ServeHTTP(w, r)
+	}), render)
+}
+
+// limitRender wraps render, giving it at most d to render each Reason, as
+// described for GracefullyRenderTimeout.
+func limitRender(render RequestRenderer, d time.Duration) RequestRenderer {
+	return func(w http.ResponseWriter, r *http.Request, reason Reason) {
+		b := &bufferedWriter{header: w.Header().Clone()}
+		done := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				done <- recover()
+			}()
+			render(b, r, reason)
+		}()
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case p := <-done:
+			if p != nil {
+				panic(p)
+			}
+			h := w.Header()
+			for k := range h {
+				delete(h, k)
+			}
+			for k, vs := range b.header {
+				h[k] = vs
+			}
+			b.flush(w)
+		case <-t.C:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
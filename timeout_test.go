@@ -0,0 +1,81 @@
+package httpanic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGracefullyRenderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	for tn, tc := range map[string]struct {
+		render     Renderer
+		wantStatus int
+		wantBody   string
+	}{
+		"in time": {
+			render:     AsText,
+			wantStatus: http.StatusTeapot,
+			wantBody:   "rut-ro raggy\n",
+		},
+		"timed out": {
+			render: func(w http.ResponseWriter, reason Reason) {
+				<-release
+				AsText(w, reason)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			handler := GracefullyRenderTimeout(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("X-Widget", "sprocket")
+				panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+			}), tc.render, 50*time.Millisecond)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("GracefullyRenderTimeout(): status got: %v, want: %v", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("GracefullyRenderTimeout():\n got:%q\nwant:%q\n", got, tc.wantBody)
+			}
+			if got := rec.Header().Get("X-Widget"); got != "sprocket" {
+				t.Errorf("GracefullyRenderTimeout(): X-Widget got: %q, want: %q", got, "sprocket")
+			}
+		})
+	}
+}
+
+func TestGracefullyRenderTimeoutRenderPanics(t *testing.T) {
+	defer func() {
+		if got := recover(); got != "render failed" {
+			t.Errorf("GracefullyRenderTimeout(): recovered got: %v, want: %v", got, "render failed")
+		}
+	}()
+	GracefullyRenderTimeout(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(errForTesting)
+	}), func(http.ResponseWriter, Reason) {
+		panic("render failed")
+	}, time.Second).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestGracefullyRenderTimeoutNested(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	handler := GracefullyRender(GracefullyRenderTimeout(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(Because(errForTesting, WithStatus(http.StatusTeapot)))
+	}), func(w http.ResponseWriter, reason Reason) {
+		<-release
+		AsText(w, reason)
+	}, 50*time.Millisecond), AsText)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("GracefullyRenderTimeout(): status got: %v, want: %v", rec.Code, http.StatusInternalServerError)
+	}
+	if got := rec.Body.String(); got != "" {
+		t.Errorf("GracefullyRenderTimeout(): body got: %q, want none", got)
+	}
+}
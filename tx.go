@@ -0,0 +1,18 @@
+package httpanic
+
+// WithTx runs fn, rolling back tx if fn panics, as it does with a Reason when
+// a request cannot be served. The panic is not recovered from, and continues
+// unchanged once tx has been rolled back, so that it is handled by the
+// middleware as usual. Any error from rolling back is discarded, since the
+// panic already describes what went wrong. If fn returns normally, tx is left
+// for the caller to commit.
+func WithTx(tx interface{ Rollback() error }, fn func()) {
+	returned := false
+	defer func() {
+		if !returned {
+			tx.Rollback()
+		}
+	}()
+	fn()
+	returned = true
+}
@@ -0,0 +1,75 @@
+package httpanic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeTx counts the times it is rolled back.
+type fakeTx struct {
+	rollbacks int
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.rollbacks++
+	return errors.New("already rolled back")
+}
+
+func TestWithTx(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		p             interface{}
+		wantRollbacks int
+	}{
+		"returned": {},
+		"reason": {
+			p:             Because(errForTesting, WithStatus(http.StatusConflict)),
+			wantRollbacks: 1,
+		},
+		"error": {
+			p:             errForTesting,
+			wantRollbacks: 1,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			tx := &fakeTx{}
+			var got interface{}
+			func() {
+				defer func() {
+					got = recover()
+				}()
+				WithTx(tx, func() {
+					if tc.p != nil {
+						panic(tc.p)
+					}
+				})
+			}()
+			if tx.rollbacks != tc.wantRollbacks {
+				t.Errorf("WithTx(): rolled back %d times, want %d", tx.rollbacks, tc.wantRollbacks)
+			}
+			if diff := cmp.Diff(tc.p, got, equateReasons); diff != "" {
+				t.Errorf("WithTx(): recovered mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWithTxGracefully(t *testing.T) {
+	tx := &fakeTx{}
+	handler := GracefullyRender(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		WithTx(tx, func() {
+			panic(Because(errForTesting, WithStatus(http.StatusConflict)))
+		})
+	}), StatusOnly)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/widgets", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("WithTx(): status got: %v, want: %v", rec.Code, http.StatusConflict)
+	}
+	if tx.rollbacks != 1 {
+		t.Errorf("WithTx(): rolled back %d times, want 1", tx.rollbacks)
+	}
+}
@@ -0,0 +1,24 @@
+module github.com/cfunkhouser/httpanic/validation
+
+go 1.18
+
+require (
+	github.com/cfunkhouser/httpanic v0.0.0-20261014102555-96928b21c495
+	github.com/go-playground/validator/v10 v10.22.1
+	github.com/google/go-cmp v0.5.9
+)
+
+require (
+	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
+	github.com/go-playground/locales v0.14.1 // indirect
+	github.com/go-playground/universal-translator v0.18.1 // indirect
+	github.com/leodido/go-urn v1.4.0 // indirect
+	golang.org/x/crypto v0.19.0 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+// Build against the root module in this repository during development. Users
+// of this module ignore the replacement, and get the version required above.
+replace github.com/cfunkhouser/httpanic => ../
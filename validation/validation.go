@@ -0,0 +1,43 @@
+// Package validation adapts the errors produced by
+// github.com/go-playground/validator to Details about a Reason for panicking.
+// It is kept separate so that the httpanic package itself carries no
+// dependency on the validator.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cfunkhouser/httpanic"
+	"github.com/go-playground/validator/v10"
+)
+
+// FromValidationErrors returns Details describing each of the field errors in
+// err, along with status 422 Unprocessable Entity, if err is or wraps a
+// validator.ValidationErrors. Otherwise, it returns no Details.
+func FromValidationErrors(err error) []httpanic.Detail {
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	fieldErrors := make([]httpanic.FieldError, len(ve))
+	for i, fe := range ve {
+		fieldErrors[i] = httpanic.FieldError{
+			Field:   fe.Field(),
+			Message: message(fe),
+		}
+	}
+	return []httpanic.Detail{
+		httpanic.WithStatus(http.StatusUnprocessableEntity),
+		httpanic.WithFieldErrors(fieldErrors...),
+	}
+}
+
+// message describes the validation rule the field failed.
+func message(fe validator.FieldError) string {
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed the %q validation", fe.Tag()+"="+fe.Param())
+	}
+	return fmt.Sprintf("failed the %q validation", fe.Tag())
+}
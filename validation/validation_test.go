@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cfunkhouser/httpanic"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/go-cmp/cmp"
+)
+
+type widget struct {
+	Name string `validate:"required"`
+	Size int    `validate:"min=1"`
+}
+
+func TestFromValidationErrors(t *testing.T) {
+	err := validator.New().Struct(widget{})
+	for tn, tc := range map[string]struct {
+		err             error
+		wantStatus      int
+		wantFieldErrors []httpanic.FieldError
+	}{
+		"validation errors": {
+			err:        err,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFieldErrors: []httpanic.FieldError{
+				{Field: "Name", Message: `failed the "required" validation`},
+				{Field: "Size", Message: `failed the "min=1" validation`},
+			},
+		},
+		"wrapped validation errors": {
+			err:        fmt.Errorf("validating widget: %w", err),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFieldErrors: []httpanic.FieldError{
+				{Field: "Name", Message: `failed the "required" validation`},
+				{Field: "Size", Message: `failed the "min=1" validation`},
+			},
+		},
+		"other error": {
+			err:        errors.New("this is an error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			reason := httpanic.Because(tc.err, FromValidationErrors(tc.err)...)
+			if reason.Status != tc.wantStatus {
+				t.Errorf("FromValidationErrors(): status got: %v, want: %v", reason.Status, tc.wantStatus)
+			}
+			if diff := cmp.Diff(tc.wantFieldErrors, reason.FieldErrors); diff != "" {
+				t.Errorf("FromValidationErrors(): field errors mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
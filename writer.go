@@ -0,0 +1,248 @@
+package httpanic
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// responseWriter wraps the http.ResponseWriter given to a handler, keeping
+// track of whether the response has been committed to the client. It is made
+// by trackWriter, which adds the optional interfaces of the wrapped
+// http.ResponseWriter.
+//
+// Once committed, further calls to WriteHeader are dropped rather than passed
+// on, since they could not change the response anyway. This keeps a Renderer
+// from provoking "superfluous response.WriteHeader call" warnings when the
+// handler panics after writing its status, including when the wrapped
+// http.ResponseWriter belongs to http.TimeoutHandler. After TimeoutHandler
+// times out, it has served its own response and discards anything written by
+// the handler or a Renderer, so nothing is ever written twice.
+type responseWriter struct {
+	http.ResponseWriter
+	committed bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.committed {
+		return
+	}
+	// Informational responses, like 103 Early Hints, may be followed by the
+	// final status, so they do not commit the response. 101 Switching Protocols
+	// is the exception, because there is nothing to follow it.
+	w.committed = !informational(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// informational reports whether status is a 1xx status which does not end the
+// response.
+func informational(status int) bool {
+	return status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.committed = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// tracked returns the responseWriter, however it has been composed by
+// trackWriter.
+func (w *responseWriter) tracked() *responseWriter {
+	return w
+}
+
+// flusher implements http.Flusher for a responseWriter wrapping one.
+type flusher struct{ *responseWriter }
+
+func (w flusher) Flush() {
+	w.committed = true
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijacker implements http.Hijacker for a responseWriter wrapping one.
+type hijacker struct{ *responseWriter }
+
+func (w hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.committed = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// pusher implements http.Pusher for a responseWriter wrapping one. Pushing does
+// not commit the response.
+type pusher struct{ *responseWriter }
+
+func (w pusher) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// readerFrom implements io.ReaderFrom for a responseWriter wrapping one, so
+// that http.ServeContent and friends keep using sendfile.
+type readerFrom struct{ *responseWriter }
+
+func (w readerFrom) ReadFrom(r io.Reader) (int64, error) {
+	w.committed = true
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+// trackWriter wraps w in a responseWriter, which implements http.Flusher,
+// http.Hijacker, http.Pusher and io.ReaderFrom exactly when w does, so that
+// handlers asserting those interfaces find what the server really supports.
+func trackWriter(w http.ResponseWriter) http.ResponseWriter {
+	rw := &responseWriter{ResponseWriter: w}
+	f, h, p, rf := flusher{rw}, hijacker{rw}, pusher{rw}, readerFrom{rw}
+	_, canFlush := w.(http.Flusher)
+	_, canHijack := w.(http.Hijacker)
+	_, canPush := w.(http.Pusher)
+	_, canReadFrom := w.(io.ReaderFrom)
+	switch {
+	case canFlush && canHijack && canPush && canReadFrom:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			pusher
+			readerFrom
+		}{rw, f, h, p, rf}
+	case canFlush && canHijack && canPush:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			pusher
+		}{rw, f, h, p}
+	case canFlush && canHijack && canReadFrom:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			readerFrom
+		}{rw, f, h, rf}
+	case canFlush && canPush && canReadFrom:
+		return struct {
+			*responseWriter
+			flusher
+			pusher
+			readerFrom
+		}{rw, f, p, rf}
+	case canHijack && canPush && canReadFrom:
+		return struct {
+			*responseWriter
+			hijacker
+			pusher
+			readerFrom
+		}{rw, h, p, rf}
+	case canFlush && canHijack:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+		}{rw, f, h}
+	case canFlush && canPush:
+		return struct {
+			*responseWriter
+			flusher
+			pusher
+		}{rw, f, p}
+	case canFlush && canReadFrom:
+		return struct {
+			*responseWriter
+			flusher
+			readerFrom
+		}{rw, f, rf}
+	case canHijack && canPush:
+		return struct {
+			*responseWriter
+			hijacker
+			pusher
+		}{rw, h, p}
+	case canHijack && canReadFrom:
+		return struct {
+			*responseWriter
+			hijacker
+			readerFrom
+		}{rw, h, rf}
+	case canPush && canReadFrom:
+		return struct {
+			*responseWriter
+			pusher
+			readerFrom
+		}{rw, p, rf}
+	case canFlush:
+		return struct {
+			*responseWriter
+			flusher
+		}{rw, f}
+	case canHijack:
+		return struct {
+			*responseWriter
+			hijacker
+		}{rw, h}
+	case canPush:
+		return struct {
+			*responseWriter
+			pusher
+		}{rw, p}
+	case canReadFrom:
+		return struct {
+			*responseWriter
+			readerFrom
+		}{rw, rf}
+	}
+	return rw
+}
+
+// started reports whether the response being written by w has already been
+// committed to the client. Only http.ResponseWriters provided by this package's
+// middleware are tracked; any other is assumed not to have started.
+func started(w http.ResponseWriter) bool {
+	rw, ok := w.(interface{ tracked() *responseWriter })
+	return ok && rw.tracked().committed
+}
+
+// bufferedWriter is an http.ResponseWriter which holds the status and body
+// written to it until they are flushed to another http.ResponseWriter.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// flush sends the buffered response to w, with Content-Length set to the
+// length of the body. Headers are not copied; the bufferedWriter is expected to
+// share them with w. If the status does not allow a body, only the status is
+// sent. If nothing was written, nothing is sent.
+func (b *bufferedWriter) flush(w http.ResponseWriter) {
+	if b.status == 0 {
+		return
+	}
+	if !bodyAllowed(b.status) {
+		w.WriteHeader(b.status)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(b.body.Len()))
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
@@ -0,0 +1,201 @@
+package httpanic
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStarted(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		write func(http.ResponseWriter)
+		want  bool
+	}{
+		"nothing written": {
+			write: func(http.ResponseWriter) {},
+		},
+		"header modified": {
+			write: func(w http.ResponseWriter) {
+				w.Header().Set("X-Test", "yes")
+			},
+		},
+		"informational status written": {
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusEarlyHints)
+			},
+		},
+		"switching protocols written": {
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusSwitchingProtocols)
+			},
+			want: true,
+		},
+		"status written": {
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusAccepted)
+			},
+			want: true,
+		},
+		"body written": {
+			write: func(w http.ResponseWriter) {
+				w.Write([]byte("hello"))
+			},
+			want: true,
+		},
+		"flushed": {
+			write: func(w http.ResponseWriter) {
+				w.(http.Flusher).Flush()
+			},
+			want: true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			w := trackWriter(httptest.NewRecorder())
+			tc.write(w)
+			if got := started(w); got != tc.want {
+				t.Errorf("started(): got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStartedUntrackedWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.WriteHeader(http.StatusAccepted)
+	if started(rec) {
+		t.Error("started(): got: true for an untracked ResponseWriter, want: false")
+	}
+}
+
+// fullWriter is an http.ResponseWriter which implements every optional
+// interface preserved by trackWriter, as the one given to handlers by an
+// HTTP/1.1 server does, along with http.Pusher, as for HTTP/2.
+type fullWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func (fullWriter) Push(string, *http.PushOptions) error {
+	return nil
+}
+
+func (w fullWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestTrackWriterInterfaces(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		w            http.ResponseWriter
+		wantFlush    bool
+		wantHijack   bool
+		wantPush     bool
+		wantReadFrom bool
+	}{
+		"plain": {
+			w: struct{ http.ResponseWriter }{httptest.NewRecorder()},
+		},
+		"flusher": {
+			w:         httptest.NewRecorder(),
+			wantFlush: true,
+		},
+		"hijacker without push": {
+			w: struct {
+				http.ResponseWriter
+				http.Hijacker
+			}{httptest.NewRecorder(), fullWriter{}},
+			wantHijack: true,
+		},
+		"pusher without hijack": {
+			w: struct {
+				http.ResponseWriter
+				http.Flusher
+				http.Pusher
+			}{httptest.NewRecorder(), httptest.NewRecorder(), fullWriter{}},
+			wantFlush: true,
+			wantPush:  true,
+		},
+		"everything": {
+			w:            fullWriter{httptest.NewRecorder()},
+			wantFlush:    true,
+			wantHijack:   true,
+			wantPush:     true,
+			wantReadFrom: true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			w := trackWriter(tc.w)
+			if _, got := w.(http.Flusher); got != tc.wantFlush {
+				t.Errorf("trackWriter(): http.Flusher got: %v, want: %v", got, tc.wantFlush)
+			}
+			if _, got := w.(http.Hijacker); got != tc.wantHijack {
+				t.Errorf("trackWriter(): http.Hijacker got: %v, want: %v", got, tc.wantHijack)
+			}
+			if _, got := w.(http.Pusher); got != tc.wantPush {
+				t.Errorf("trackWriter(): http.Pusher got: %v, want: %v", got, tc.wantPush)
+			}
+			if _, got := w.(io.ReaderFrom); got != tc.wantReadFrom {
+				t.Errorf("trackWriter(): io.ReaderFrom got: %v, want: %v", got, tc.wantReadFrom)
+			}
+			if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok || u.Unwrap() != tc.w {
+				t.Errorf("trackWriter(): Unwrap does not return the wrapped ResponseWriter")
+			}
+			w.Write([]byte("hello"))
+			if !started(w) {
+				t.Errorf("started(): got: false after Write, want: true")
+			}
+		})
+	}
+}
+
+func TestTrackWriterCommits(t *testing.T) {
+	for tn, use := range map[string]func(http.ResponseWriter){
+		"hijacked": func(w http.ResponseWriter) {
+			w.(http.Hijacker).Hijack()
+		},
+		"read from": func(w http.ResponseWriter) {
+			w.(io.ReaderFrom).ReadFrom(strings.NewReader("hello"))
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			w := trackWriter(fullWriter{httptest.NewRecorder()})
+			use(w)
+			if !started(w) {
+				t.Errorf("started(): got: false, want: true")
+			}
+		})
+	}
+	w := trackWriter(fullWriter{httptest.NewRecorder()})
+	w.(http.Pusher).Push("/style.css", nil)
+	if started(w) {
+		t.Errorf("started(): got: true after Push, want: false")
+	}
+}
+
+func TestGracefullyPreservesInterfaces(t *testing.T) {
+	var flusher, hijacker bool
+	handler := Gracefully(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !flusher || hijacker {
+		t.Errorf("Gracefully(): got Flusher: %v, Hijacker: %v, want: true, false", flusher, hijacker)
+	}
+}
+
+func TestResponseWriterDropsSuperfluousWriteHeader(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseWriter{ResponseWriter: rec}
+	w.Write([]byte("hello"))
+	w.WriteHeader(http.StatusInternalServerError)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status got: %v, want: %v", rec.Code, http.StatusOK)
+	}
+}